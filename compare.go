@@ -0,0 +1,435 @@
+package reflector
+
+import (
+	"container/list"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+)
+
+// OperatorFunc compares a with b, like a < b for the operator "<".
+// Non-nil pointers are de-referenced before operators are called, and b is
+// an invalid Reflector if CompareTo was called with nil.
+type OperatorFunc func(a, b *Reflector) (bool, error)
+
+type operatorKey struct {
+	name string
+	typ  reflect.Type
+}
+
+// OperatorRegistry holds custom comparison operators, which are consulted
+// by CompareTo, and everything built on it like SortByField, before the
+// builtin operators.
+//
+// Operators registered for the type of the left operand take precedence
+// over operators registered for all types, so builtin operators like "<"
+// can be specialized for single types.
+// An OperatorRegistry is safe for concurrent use.
+type OperatorRegistry struct {
+	mu    sync.RWMutex
+	names map[string]OperatorFunc
+	types map[operatorKey]OperatorFunc
+	// size is the number of operators, so empty registries can be skipped
+	// without locking.
+	size int32
+}
+
+// DefaultOperators is the global registry that is used by CompareTo.
+var DefaultOperators = NewOperatorRegistry()
+
+// NewOperatorRegistry returns an empty registry, for operators that are
+// only used with CompareToWith and CompareOptions.Operators.
+func NewOperatorRegistry() *OperatorRegistry {
+	return &OperatorRegistry{
+		names: make(map[string]OperatorFunc),
+		types: make(map[operatorKey]OperatorFunc),
+	}
+}
+
+// Register registers an operator for values of all types.
+// Registering a nil function removes the operator.
+func (o *OperatorRegistry) Register(name string, f OperatorFunc) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if f == nil {
+		delete(o.names, name)
+	} else {
+		o.names[name] = f
+	}
+	o.updateSize()
+}
+
+// RegisterType registers an operator for left operands of the given type.
+// typ should not be a pointer type, since operands are de-referenced.
+// Registering a nil function removes the operator.
+func (o *OperatorRegistry) RegisterType(name string, typ reflect.Type, f OperatorFunc) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	key := operatorKey{name, typ}
+	if f == nil {
+		delete(o.types, key)
+	} else {
+		o.types[key] = f
+	}
+	o.updateSize()
+}
+
+// updateSize must be called with the lock held.
+func (o *OperatorRegistry) updateSize() {
+	atomic.StoreInt32(&o.size, int32(len(o.names)+len(o.types)))
+}
+
+// Lookup returns the operator for the given name and left operand type,
+// or nil. typ may be nil to only look up operators for all types.
+func (o *OperatorRegistry) Lookup(name string, typ reflect.Type) OperatorFunc {
+	if atomic.LoadInt32(&o.size) == 0 {
+		return nil
+	}
+
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	if typ != nil {
+		if f := o.types[operatorKey{name, typ}]; f != nil {
+			return f
+		}
+	}
+	return o.names[name]
+}
+
+// RegisterOperator registers an operator with DefaultOperators.
+func RegisterOperator(name string, f OperatorFunc) {
+	DefaultOperators.Register(name, f)
+}
+
+// RegisterTypeOperator registers an operator for left operands of the given
+// type with DefaultOperators.
+func RegisterTypeOperator(name string, typ reflect.Type, f OperatorFunc) {
+	DefaultOperators.RegisterType(name, typ, f)
+}
+
+// deref de-references non-nil pointers.
+func deref(r *Reflector) *Reflector {
+	for r.IsPtr() && !r.IsNil() {
+		r = r.Elem()
+	}
+	return r
+}
+
+// customCompare applies the operator from opts.Operators, or from
+// DefaultOperators.
+// ok is false if no operator is registered.
+func (r *Reflector) customCompare(value interface{}, operator string, opts *CompareOptions) (result bool, ok bool, err error) {
+	a := deref(r)
+	var typ reflect.Type
+	if a.IsValid() {
+		typ = a.Type()
+	}
+
+	var f OperatorFunc
+	if opts.Operators != nil {
+		f = opts.Operators.Lookup(operator, typ)
+	}
+	if f == nil {
+		f = DefaultOperators.Lookup(operator, typ)
+	}
+	if f == nil {
+		return false, false, nil
+	}
+
+	result, err = f(a, deref(Reflect(reflectValue(value))))
+	if err != nil {
+		return false, true, &Error{
+			Code:    ERR_INVALID_COMPARISON,
+			Message: "operator " + operator + " failed",
+			Err:     err,
+		}
+	}
+	return result, true, nil
+}
+
+// CompareOptions control CompareToWith.
+// A nil *CompareOptions is equivalent to the zero value.
+type CompareOptions struct {
+	// CoerceNil enables the legacy comparison semantics, which compare nil
+	// and zero values, including empty strings, as the number 0.
+	// So nil = 0 and "" < 1 are true.
+	CoerceNil bool
+
+	// IgnoreCase compares strings case-insensitively, with Unicode simple
+	// case folding, so "Zebra" sorts after "apple".
+	// Regular expressions are matched case-insensitively.
+	IgnoreCase bool
+
+	// Natural compares runs of digits in strings numerically, so "item2"
+	// sorts before "item10".
+	Natural bool
+
+	// Normalize is applied to strings before they are compared, except for
+	// regular expressions. Unicode normalization is not built in, so
+	// "\u00e9" and "e\u0301" differ unless Normalize is set, for example
+	// to norm.NFC.String from golang.org/x/text/unicode/norm.
+	Normalize func(string) string
+
+	// Operators holds custom operators, which take precedence over those
+	// in DefaultOperators.
+	Operators *OperatorRegistry
+}
+
+// prepareString applies the string options to s.
+func (o *CompareOptions) prepareString(s string) string {
+	if o.Normalize != nil {
+		s = o.Normalize(s)
+	}
+	if o.IgnoreCase {
+		s = strings.Map(foldRune, s)
+	}
+	return s
+}
+
+// foldRune maps all runes that are equal under simple case folding, like
+// "K", "k" and the Kelvin sign, to the same lower case rune.
+func foldRune(r rune) rune {
+	min := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < min {
+			min = f
+		}
+	}
+	return unicode.ToLower(min)
+}
+
+// compareStrings compares a and b like strings.Compare, or in natural
+// order.
+func (o *CompareOptions) compareStrings(a, b string) int {
+	if o.Natural {
+		return compareNatural(a, b)
+	}
+	return strings.Compare(a, b)
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// compareNatural compares strings like strings.Compare, but compares runs
+// of digits numerically.
+// Strings that only differ in leading zeros are ordered byte-wise.
+func compareNatural(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if !isDigit(a[i]) || !isDigit(b[j]) {
+			if a[i] != b[j] {
+				if a[i] < b[j] {
+					return -1
+				}
+				return 1
+			}
+			i++
+			j++
+			continue
+		}
+
+		x, y := i, j
+		for i < len(a) && isDigit(a[i]) {
+			i++
+		}
+		for j < len(b) && isDigit(b[j]) {
+			j++
+		}
+		m := strings.TrimLeft(a[x:i], "0")
+		n := strings.TrimLeft(b[y:j], "0")
+		if len(m) != len(n) {
+			if len(m) < len(n) {
+				return -1
+			}
+			return 1
+		}
+		if c := strings.Compare(m, n); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case i < len(a):
+		return 1
+	case j < len(b):
+		return -1
+	}
+	return strings.Compare(a, b)
+}
+
+// regexCacheSize is the number of compiled patterns kept by compileRegex.
+const regexCacheSize = 256
+
+// regexCache holds recently used patterns. It is bounded, since patterns
+// passed to CompareTo may come from user input.
+var regexCache = &regexLRU{
+	items: make(map[string]*list.Element),
+	order: list.New(),
+}
+
+type regexLRU struct {
+	mu    sync.Mutex
+	items map[string]*list.Element
+	// order holds the patterns, most recently used first.
+	order *list.List
+}
+
+type regexEntry struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+func (c *regexLRU) get(pattern string) *regexp.Regexp {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[pattern]; ok {
+		c.order.MoveToFront(elem)
+		return elem.Value.(*regexEntry).re
+	}
+	return nil
+}
+
+func (c *regexLRU) add(pattern string, re *regexp.Regexp) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.items[pattern]; ok {
+		return
+	}
+	c.items[pattern] = c.order.PushFront(&regexEntry{pattern, re})
+	if c.order.Len() > regexCacheSize {
+		oldest := c.order.Remove(c.order.Back()).(*regexEntry)
+		delete(c.items, oldest.pattern)
+	}
+}
+
+// compileRegex compiles a regular expression, caching the result.
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	if re := regexCache.get(pattern); re != nil {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexCache.add(pattern, re)
+	return re, nil
+}
+
+// collection returns value as a slice or array, de-referencing pointers.
+func collection(value interface{}, operator string) (*Reflector, error) {
+	list := deref(Reflect(reflectValue(value)))
+	if !list.IsSlice() && !list.IsArray() {
+		var typ reflect.Type
+		if list.IsValid() {
+			typ = list.Type()
+		}
+		return nil, &Error{
+			Code:    ERR_INVALID_COMPARISON,
+			Source:  typ,
+			Message: operator + " requires a slice or array",
+		}
+	}
+	return list, nil
+}
+
+// listItem returns the item at index i of a slice or array, de-referencing
+// interfaces.
+func listItem(list *Reflector, i int) *Reflector {
+	v := list.value.Index(i)
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	return Reflect(v)
+}
+
+// containsItem returns true if any item of list is equal to value.
+func containsItem(list, value *Reflector, opts *CompareOptions) (bool, error) {
+	for i := 0; i < list.Len(); i++ {
+		ok, err := listItem(list, i).CompareToWith(value, "=", opts)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// compareCollection implements the operators that compare against slices,
+// or check for nil values, which do not convert their operands.
+func (r *Reflector) compareCollection(value interface{}, operator string, opts *CompareOptions) (bool, error) {
+	if operator != "is null" && operator != "not null" && !opts.CoerceNil && deref(r).IsNil() {
+		return false, nil
+	}
+
+	switch operator {
+	case "is null":
+		return r.IsNil(), nil
+	case "not null":
+		return !r.IsNil(), nil
+
+	case "in", "not in":
+		list, err := collection(value, operator)
+		if err != nil {
+			return false, err
+		}
+		found, err := containsItem(list, r, opts)
+		if err != nil {
+			return false, err
+		}
+		return found == (operator == "in"), nil
+
+	case "between":
+		bounds, err := collection(value, operator)
+		if err != nil {
+			return false, err
+		}
+		if bounds.Len() != 2 {
+			return false, &Error{
+				Code:    ERR_INVALID_COMPARISON,
+				Message: "between requires a lower and an upper bound",
+			}
+		}
+		ok, err := r.CompareToWith(listItem(bounds, 0), ">=", opts)
+		if err != nil || !ok {
+			return false, err
+		}
+		return r.CompareToWith(listItem(bounds, 1), "<=", opts)
+
+	case "contains":
+		list, err := collection(r, operator)
+		if err != nil {
+			return false, err
+		}
+		return containsItem(list, Reflect(reflectValue(value)), opts)
+
+	case "contains any":
+		list, err := collection(r, operator)
+		if err != nil {
+			return false, err
+		}
+		items, err := collection(value, operator)
+		if err != nil {
+			return false, err
+		}
+		for i := 0; i < items.Len(); i++ {
+			found, err := containsItem(list, listItem(items, i), opts)
+			if err != nil || found {
+				return found, err
+			}
+		}
+		return false, nil
+	}
+
+	return false, &Error{Code: ERR_UNKNOWN_OPERATOR, Message: operator}
+}
@@ -0,0 +1,247 @@
+package reflector_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/theduke/go-reflector"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type compareVersion string
+
+// lessVersion compares dotted versions numerically, so 1.9 < 1.10.
+func lessVersion(a, b *Reflector) (bool, error) {
+	x := strings.Split(a.Interface().(compareVersion).String(), ".")
+	y := strings.Split(b.Interface().(compareVersion).String(), ".")
+	for i := 0; i < len(x) && i < len(y); i++ {
+		m, _ := strconv.Atoi(x[i])
+		n, _ := strconv.Atoi(y[i])
+		if m != n {
+			return m < n, nil
+		}
+	}
+	return len(x) < len(y), nil
+}
+
+func (v compareVersion) String() string {
+	return string(v)
+}
+
+var _ = Describe("CompareTo", func() {
+	It("Should compare strings with patterns", func() {
+		r := R("Hello World")
+
+		Expect(r.CompareTo("hello", "ilike")).To(BeTrue())
+		Expect(r.CompareTo("hello", "like")).To(BeFalse())
+		Expect(r.CompareTo("Hello", "prefix")).To(BeTrue())
+		Expect(r.CompareTo("World", "prefix")).To(BeFalse())
+		Expect(r.CompareTo("World", "suffix")).To(BeTrue())
+		Expect(r.CompareTo("^H.*d$", "regex")).To(BeTrue())
+		Expect(r.CompareTo("^W", "regex")).To(BeFalse())
+		Expect(r.CompareTo("^W", "!regex")).To(BeTrue())
+
+		_, err := r.CompareTo("[", "regex")
+		Expect(errors.Is(err, ErrInvalidComparison)).To(BeTrue())
+
+		_, err = R(10).CompareTo(1, "prefix")
+		Expect(errors.Is(err, ErrInvalidComparison)).To(BeTrue())
+	})
+
+	It("Should match many different patterns", func() {
+		for i := 0; i < 300; i++ {
+			Expect(R(fmt.Sprintf("item%v", i)).CompareTo(fmt.Sprintf("^item%v$", i), "regex")).To(BeTrue())
+		}
+		Expect(R("item0").CompareTo("^item0$", "regex")).To(BeTrue())
+		Expect(R("item0").CompareTo("^item1$", "regex")).To(BeFalse())
+	})
+
+	It("Should check membership with in", func() {
+		Expect(R(2).CompareTo([]int{1, 2, 3}, "in")).To(BeTrue())
+		Expect(R(2).CompareTo([]float64{1, 2.5}, "in")).To(BeFalse())
+		Expect(R("b").CompareTo([]interface{}{"a", nil, "b"}, "in")).To(BeTrue())
+		Expect(R("b").CompareTo([2]string{"a", "c"}, "not in")).To(BeTrue())
+		Expect(R("a").CompareTo([]string{"a"}, "not in")).To(BeFalse())
+		Expect(R(1).CompareTo([]int{}, "in")).To(BeFalse())
+		Expect(R(nil).CompareTo([]interface{}{0, nil}, "in")).To(BeFalse())
+		Expect(R(nil).CompareTo([]int{1}, "not in")).To(BeFalse())
+
+		_, err := R(2).CompareTo(2, "in")
+		Expect(errors.Is(err, ErrInvalidComparison)).To(BeTrue())
+	})
+
+	It("Should check ranges with between", func() {
+		Expect(R(5).CompareTo([]int{1, 10}, "between")).To(BeTrue())
+		Expect(R(1).CompareTo([]int{1, 10}, "between")).To(BeTrue())
+		Expect(R(10.5).CompareTo([]int{1, 10}, "between")).To(BeFalse())
+		Expect(R("b").CompareTo([]string{"a", "c"}, "between")).To(BeTrue())
+
+		_, err := R(5).CompareTo([]int{1}, "between")
+		Expect(errors.Is(err, ErrInvalidComparison)).To(BeTrue())
+	})
+
+	It("Should check slice items with contains", func() {
+		tags := []string{"go", "rust"}
+
+		Expect(R(tags).CompareTo("go", "contains")).To(BeTrue())
+		Expect(R(tags).CompareTo("c", "contains")).To(BeFalse())
+		Expect(R(&tags).CompareTo([]string{"c", "rust"}, "contains any")).To(BeTrue())
+		Expect(R(tags).CompareTo([]string{"c", "java"}, "contains any")).To(BeFalse())
+		Expect(R([]int(nil)).CompareTo(1, "contains")).To(BeFalse())
+
+		_, err := R("go").CompareTo("g", "contains")
+		Expect(errors.Is(err, ErrInvalidComparison)).To(BeTrue())
+	})
+
+	It("Should check nil values with is null", func() {
+		var ptr *int
+		zero := 0
+
+		Expect(R(nil).CompareTo(nil, "is null")).To(BeTrue())
+		Expect(R(ptr).CompareTo(nil, "is null")).To(BeTrue())
+		Expect(R(&zero).CompareTo(nil, "is null")).To(BeFalse())
+		Expect(R(0).CompareTo(nil, "is null")).To(BeFalse())
+		Expect(R(0).CompareTo(nil, "not null")).To(BeTrue())
+		Expect(R(map[string]int(nil)).CompareTo(nil, "not null")).To(BeFalse())
+	})
+
+	It("Should compare nil and zero values", func() {
+		var ptr *int
+		zero := 0
+
+		Expect(R("abc").CompareTo("", "=")).To(BeFalse())
+		Expect(R("").CompareTo("", "=")).To(BeTrue())
+		Expect(R("").CompareTo("a", "<")).To(BeTrue())
+		Expect(R("b").CompareTo("", ">")).To(BeTrue())
+		Expect(R(5).CompareTo(0, ">")).To(BeTrue())
+		Expect(R(0).CompareTo(5, "<")).To(BeTrue())
+		Expect(R(&zero).CompareTo(0, "=")).To(BeTrue())
+		Expect(R(5*time.Second).CompareTo(time.Second, ">")).To(BeTrue())
+
+		for _, operator := range []string{"=", "!=", "<", ">=", "like"} {
+			Expect(R(ptr).CompareTo(0, operator)).To(BeFalse(), operator)
+			Expect(R(0).CompareTo(ptr, operator)).To(BeFalse(), operator)
+			Expect(R(nil).CompareTo(nil, operator)).To(BeFalse(), operator)
+		}
+		Expect(R(ptr).CompareTo([]int{0, 1}, "between")).To(BeFalse())
+
+		_, err := R("").CompareTo(1, "<")
+		Expect(errors.Is(err, ErrUncomparableValues)).To(BeTrue())
+	})
+
+	It("Should coerce nil and zero values with CoerceNil", func() {
+		var ptr *int
+		opts := &CompareOptions{CoerceNil: true}
+
+		Expect(R(ptr).CompareToWith(0, "=", opts)).To(BeTrue())
+		Expect(R(nil).CompareToWith([]int{0}, "in", opts)).To(BeTrue())
+		Expect(R("").CompareToWith(1, "<", opts)).To(BeTrue())
+		Expect(R(5).CompareToWith(0, ">", opts)).To(BeTrue())
+		Expect(R(ptr).CompareToWith(nil, "is null", opts)).To(BeTrue())
+	})
+
+	It("Should compare strings with options", func() {
+		Expect(R("Zebra").CompareTo("apple", "<")).To(BeTrue())
+		Expect(R("Zebra").CompareToWith("apple", "<", &CompareOptions{IgnoreCase: true})).To(BeFalse())
+		Expect(R("ΣΊΣΥΦΟΣ").CompareToWith("σίσυφος", "=", &CompareOptions{IgnoreCase: true})).To(BeTrue())
+		Expect(R("\u212a").CompareToWith("k", "=", &CompareOptions{IgnoreCase: true})).To(BeTrue())
+		Expect(R("Hello").CompareToWith("hel", "prefix", &CompareOptions{IgnoreCase: true})).To(BeTrue())
+		Expect(R("abc").CompareToWith(`^\D+$`, "regex", &CompareOptions{IgnoreCase: true})).To(BeTrue())
+		Expect(R("ABC").CompareToWith(`^[a-c]+$`, "regex", &CompareOptions{IgnoreCase: true})).To(BeTrue())
+		Expect(R("ABC").CompareToWith(`^[a-c]+$`, "!regex", &CompareOptions{IgnoreCase: true})).To(BeFalse())
+
+		natural := &CompareOptions{Natural: true}
+		Expect(R("item10").CompareTo("item2", "<")).To(BeTrue())
+		Expect(R("item10").CompareToWith("item2", "<", natural)).To(BeFalse())
+		Expect(R("item2").CompareToWith("item10", "<", natural)).To(BeTrue())
+		Expect(R("v1.10.0").CompareToWith("v1.9.3", ">", natural)).To(BeTrue())
+		Expect(R("a2b").CompareToWith("a2", ">", natural)).To(BeTrue())
+		Expect(R("a01").CompareToWith("a1", "=", natural)).To(BeFalse())
+		Expect(R("a01").CompareToWith("a1", "<", natural)).To(BeTrue())
+
+		composed, decomposed := "caf\u00e9", "cafe\u0301"
+		normalize := &CompareOptions{Normalize: strings.NewReplacer("e\u0301", "\u00e9").Replace}
+		Expect(R(composed).CompareTo(decomposed, "=")).To(BeFalse())
+		Expect(R(composed).CompareToWith(decomposed, "=", normalize)).To(BeTrue())
+		Expect(R(decomposed).CompareToWith([]string{composed}, "in", normalize)).To(BeTrue())
+		normalize.IgnoreCase = true
+		Expect(R("CAF\u00c9").CompareToWith(decomposed, "=", normalize)).To(BeTrue())
+	})
+
+	It("Should reject unknown operators", func() {
+		_, err := R(1).CompareTo(1, "~")
+		Expect(errors.Is(err, ErrUnknownOperator)).To(BeTrue())
+	})
+
+	Describe("Custom operators", func() {
+		It("Should use registered operators", func() {
+			RegisterOperator("divisible by", func(a, b *Reflector) (bool, error) {
+				x, err := a.ConvertTo(0)
+				if err != nil {
+					return false, err
+				}
+				y, err := b.ConvertTo(0)
+				if err != nil {
+					return false, err
+				}
+				if y.(int) == 0 {
+					return false, fmt.Errorf("division by zero")
+				}
+				return x.(int)%y.(int) == 0, nil
+			})
+			defer RegisterOperator("divisible by", nil)
+
+			Expect(R(9).CompareTo(3, "divisible by")).To(BeTrue())
+			Expect(R("10").CompareTo(3, "divisible by")).To(BeFalse())
+
+			_, err := R(9).CompareTo(0, "divisible by")
+			Expect(errors.Is(err, ErrInvalidComparison)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("division by zero"))
+
+			RegisterOperator("divisible by", nil)
+			_, err = R(9).CompareTo(3, "divisible by")
+			Expect(errors.Is(err, ErrUnknownOperator)).To(BeTrue())
+		})
+
+		It("Should use operators from the options", func() {
+			operators := NewOperatorRegistry()
+			operators.Register("<", func(a, b *Reflector) (bool, error) {
+				return a.Len() < b.Len(), nil
+			})
+			opts := &CompareOptions{Operators: operators}
+
+			Expect(R("zz").CompareToWith("aaa", "<", opts)).To(BeTrue())
+			Expect(R("zz").CompareTo("aaa", "<")).To(BeFalse())
+			Expect(DefaultOperators.Lookup("<", nil)).To(BeNil())
+
+			filtered, err := R([]string{"ccc", "a", "bb"}).MustSlice().Filter(WhereWith("", "<", "xxx", opts))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(filtered.Interface()).To(Equal([]string{"a", "bb"}))
+		})
+
+		It("Should prefer operators registered for the type", func() {
+			typ := reflect.TypeOf(compareVersion(""))
+			RegisterTypeOperator("<", typ, lessVersion)
+			defer RegisterTypeOperator("<", typ, nil)
+
+			v := compareVersion("1.10")
+			Expect(R(compareVersion("1.9")).CompareTo(compareVersion("1.10"), "<")).To(BeTrue())
+			Expect(R(&v).CompareTo(compareVersion("1.9"), "<")).To(BeFalse())
+			// Other types use the builtin operator.
+			Expect(R("1.9").CompareTo("1.10", "<")).To(BeFalse())
+
+			Expect(DefaultOperators.Lookup("<", typ)).ToNot(BeNil())
+			Expect(DefaultOperators.Lookup("<", reflect.TypeOf(""))).To(BeNil())
+
+			releases := []struct{ Version compareVersion }{{"1.10"}, {"1.2"}, {"1.9"}}
+			Expect(R(releases).MustSlice().SortByField("Version", true)).To(Succeed())
+			Expect(releases).To(Equal([]struct{ Version compareVersion }{{"1.2"}, {"1.9"}, {"1.10"}}))
+		})
+	})
+})
@@ -0,0 +1,130 @@
+package reflector
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// ConverterFunc converts value to the target type.
+// It may return ErrSkipConverter to fall back to the builtin conversion
+// rules.
+type ConverterFunc func(value *Reflector, target reflect.Type) (interface{}, error)
+
+type converterKey struct {
+	source reflect.Type
+	target reflect.Type
+}
+
+// ConverterRegistry holds custom conversion functions, which are consulted
+// by ConvertToType and all methods that convert values before the builtin
+// conversion rules are applied.
+//
+// Converters registered for a source and target type take precedence over
+// converters registered for a target kind.
+// A ConverterRegistry is safe for concurrent use.
+type ConverterRegistry struct {
+	mu    sync.RWMutex
+	types map[converterKey]ConverterFunc
+	kinds map[reflect.Kind]ConverterFunc
+}
+
+// DefaultConverters is the global registry that is used for all
+// conversions. Registries passed with ConvertOptions are consulted first.
+var DefaultConverters = NewConverterRegistry()
+
+func NewConverterRegistry() *ConverterRegistry {
+	return &ConverterRegistry{
+		types: make(map[converterKey]ConverterFunc),
+		kinds: make(map[reflect.Kind]ConverterFunc),
+	}
+}
+
+// Register registers a converter from values of the source type to the
+// target type.
+// Registering a nil function removes the converter.
+func (c *ConverterRegistry) Register(source, target reflect.Type, f ConverterFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := converterKey{source, target}
+	if f == nil {
+		delete(c.types, key)
+	} else {
+		c.types[key] = f
+	}
+}
+
+// RegisterKind registers a converter for all target types of the given kind,
+// like custom enum types based on int.
+// Registering a nil function removes the converter.
+func (c *ConverterRegistry) RegisterKind(target reflect.Kind, f ConverterFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if f == nil {
+		delete(c.kinds, target)
+	} else {
+		c.kinds[target] = f
+	}
+}
+
+// Lookup returns the converter for the given types, or nil.
+func (c *ConverterRegistry) Lookup(source, target reflect.Type) ConverterFunc {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if f := c.types[converterKey{source, target}]; f != nil {
+		return f
+	}
+	return c.kinds[target.Kind()]
+}
+
+// convert tries the converters of the registry.
+// ok is false if no converter handled the conversion.
+func (c *ConverterRegistry) convert(value *Reflector, target reflect.Type) (result interface{}, ok bool, err error) {
+	f := c.Lookup(value.Type(), target)
+	if f == nil {
+		return nil, false, nil
+	}
+
+	result, err = f(value, target)
+	if errors.Is(err, ErrSkipConverter) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, true, &Error{
+			Code:   ERR_UNCONVERTABLE_TYPES,
+			Source: value.Type(),
+			Target: target,
+			Err:    err,
+		}
+	}
+
+	// Make sure the result has the exact target type.
+	resultValue := reflect.ValueOf(result)
+	if !resultValue.IsValid() {
+		return reflect.Zero(target).Interface(), true, nil
+	}
+	if resultValue.Type() != target {
+		if !resultValue.Type().ConvertibleTo(target) {
+			return nil, true, &Error{
+				Code:    ERR_UNCONVERTABLE_TYPES,
+				Source:  value.Type(),
+				Target:  target,
+				Message: "converter returned " + resultValue.Type().String(),
+			}
+		}
+		result = resultValue.Convert(target).Interface()
+	}
+	return result, true, nil
+}
+
+// customConvert applies the converters from opts and DefaultConverters.
+func customConvert(value *Reflector, target reflect.Type, opts *ConvertOptions) (interface{}, bool, error) {
+	if opts != nil && opts.Converters != nil {
+		if result, ok, err := opts.Converters.convert(value, target); ok {
+			return result, true, err
+		}
+	}
+	return DefaultConverters.convert(value, target)
+}
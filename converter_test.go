@@ -0,0 +1,148 @@
+package reflector_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	. "github.com/theduke/go-reflector"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type converterColor int
+
+const (
+	converterRed converterColor = iota + 1
+	converterGreen
+)
+
+type converterStruct struct {
+	Timeout time.Duration
+	Color   converterColor
+	Colors  []converterColor
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	colorType    = reflect.TypeOf(converterColor(0))
+)
+
+func parseDuration(value *Reflector, target reflect.Type) (interface{}, error) {
+	return time.ParseDuration(value.Interface().(string))
+}
+
+func parseColor(value *Reflector, target reflect.Type) (interface{}, error) {
+	if target != colorType || !value.IsString() {
+		return nil, ErrSkipConverter
+	}
+	switch strings.ToLower(value.Interface().(string)) {
+	case "red":
+		return converterRed, nil
+	case "green":
+		return converterGreen, nil
+	}
+	return nil, fmt.Errorf("unknown color %v", value.Interface())
+}
+
+var _ = Describe("ConverterRegistry", func() {
+	var registry *ConverterRegistry
+
+	BeforeEach(func() {
+		registry = NewConverterRegistry()
+		registry.Register(reflect.TypeOf(""), durationType, parseDuration)
+		registry.RegisterKind(reflect.Int, parseColor)
+	})
+
+	It("Should look up converters", func() {
+		Expect(registry.Lookup(reflect.TypeOf(""), durationType)).ToNot(BeNil())
+		Expect(registry.Lookup(reflect.TypeOf(""), colorType)).ToNot(BeNil())
+		Expect(registry.Lookup(reflect.TypeOf(""), reflect.TypeOf(""))).To(BeNil())
+
+		registry.Register(reflect.TypeOf(""), durationType, nil)
+		Expect(registry.Lookup(reflect.TypeOf(""), durationType)).To(BeNil())
+	})
+
+	It("Should convert with per call registries", func() {
+		opts := &ConvertOptions{Converters: registry}
+
+		Expect(R("1m").ConvertToTypeWith(durationType, opts)).To(Equal(time.Minute))
+		Expect(R("green").ConvertToTypeWith(colorType, opts)).To(Equal(converterGreen))
+	})
+
+	It("Should fall back to builtin rules", func() {
+		opts := &ConvertOptions{Converters: registry}
+
+		Expect(R("5").ConvertToTypeWith(reflect.TypeOf(0), opts)).To(Equal(5))
+		Expect(R(2).ConvertToTypeWith(colorType, opts)).To(Equal(converterGreen))
+	})
+
+	It("Should wrap converter errors", func() {
+		_, err := R("blue").ConvertToTypeWith(colorType, &ConvertOptions{Converters: registry})
+		Expect(errors.Is(err, ErrUnconvertableTypes)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("unknown color blue"))
+	})
+
+	It("Should reject results of the wrong type", func() {
+		registry.Register(reflect.TypeOf(""), durationType, func(value *Reflector, target reflect.Type) (interface{}, error) {
+			return "nope", nil
+		})
+		_, err := R("1m").ConvertToTypeWith(durationType, &ConvertOptions{Converters: registry})
+		Expect(errors.Is(err, ErrUnconvertableTypes)).To(BeTrue())
+	})
+
+	It("Should use converters when setting values", func() {
+		opts := &ConvertOptions{Mode: ConvertLenient, Converters: registry}
+		s := &converterStruct{}
+
+		Expect(R(s).MustStruct().SetFieldWith("Timeout", "2s", opts)).ToNot(HaveOccurred())
+		Expect(s.Timeout).To(Equal(2 * time.Second))
+
+		Expect(R(&s.Color).Elem().SetWith(R("red"), opts)).ToNot(HaveOccurred())
+		Expect(s.Color).To(Equal(converterRed))
+	})
+
+	It("Should use converters in FromMap and slices", func() {
+		s := &converterStruct{}
+		err := R(s).MustStruct().FromMapWith(map[string]interface{}{
+			"Timeout": "1h",
+			"Colors":  []interface{}{"red", "green"},
+		}, &MapOptions{ConvertOptions: ConvertOptions{Mode: ConvertLenient, Converters: registry}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.Timeout).To(Equal(time.Hour))
+		Expect(s.Colors).To(Equal([]converterColor{converterRed, converterGreen}))
+
+		colors, err := R([]string{"green"}).MustSlice().ConvertToTypeWith(colorType, &ConvertOptions{Converters: registry})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(colors).To(Equal([]converterColor{converterGreen}))
+	})
+
+	It("Should use the default registry", func() {
+		DefaultConverters.Register(reflect.TypeOf(""), durationType, parseDuration)
+		defer DefaultConverters.Register(reflect.TypeOf(""), durationType, nil)
+
+		v := time.Duration(0)
+		Expect(R(&v).Elem().SetValue("3s", true)).ToNot(HaveOccurred())
+		Expect(v).To(Equal(3 * time.Second))
+	})
+
+	It("Should use the default registry for slice items", func() {
+		DefaultConverters.Register(reflect.TypeOf(""), colorType, parseColor)
+		defer DefaultConverters.Register(reflect.TypeOf(""), colorType, nil)
+
+		colors, err := R([]string{"red", "green"}).MustSlice().ConvertToType(colorType)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(colors).To(Equal([]converterColor{converterRed, converterGreen}))
+
+		colors, err = R([]string{"green"}).ConvertToType(reflect.TypeOf([]converterColor{}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(colors).To(Equal([]converterColor{converterGreen}))
+
+		_, err = R([]string{"blue"}).MustSlice().ConvertToType(colorType)
+		Expect(err).To(HaveOccurred())
+		Expect(err.(*Error).Path).To(Equal("[0]"))
+	})
+})
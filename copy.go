@@ -0,0 +1,185 @@
+package reflector
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// CopyOptions control DeepCopyWith.
+// A nil *CopyOptions is equivalent to the zero value.
+type CopyOptions struct {
+	// IncludeUnexported deep copies unexported struct fields, which are
+	// accessed through package unsafe.
+	// By default, they are copied shallowly, like with an assignment.
+	IncludeUnexported bool
+
+	// ShallowTypes are copied with a plain assignment instead of being
+	// copied recursively, like sync.Mutex or types holding resources.
+	// Channels, functions and time.Time are always copied shallowly.
+	ShallowTypes []reflect.Type
+}
+
+// copyKey identifies a pointer, map or slice that was already copied.
+type copyKey struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+type copier struct {
+	opts   *CopyOptions
+	copies map[copyKey]reflect.Value
+}
+
+func (c *copier) shallow(typ reflect.Type) bool {
+	if typ == timeType {
+		return true
+	}
+	for _, t := range c.opts.ShallowTypes {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// copyInto copies src into the settable value dst, which must be a zero
+// value of the same type.
+func (c *copier) copyInto(dst, src reflect.Value) {
+	if c.shallow(src.Type()) {
+		dst.Set(src)
+		return
+	}
+
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+		key := copyKey{ptr: src.Pointer(), typ: src.Type()}
+		if cp, ok := c.copies[key]; ok {
+			dst.Set(cp)
+			return
+		}
+		cp := reflect.New(src.Type().Elem())
+		c.copies[key] = cp
+		c.copyInto(cp.Elem(), src.Elem())
+		dst.Set(cp)
+
+	case reflect.Interface:
+		if src.IsNil() {
+			return
+		}
+		elem := src.Elem()
+		cp := reflect.New(elem.Type()).Elem()
+		c.copyInto(cp, elem)
+		dst.Set(cp)
+
+	case reflect.Struct:
+		// Copies unexported fields shallowly.
+		dst.Set(src)
+
+		if !src.CanAddr() {
+			// Unexported fields can only be exposed on addressable structs.
+			addressable := reflect.New(src.Type()).Elem()
+			addressable.Set(src)
+			src = addressable
+		}
+		for i := 0; i < src.NumField(); i++ {
+			srcField, dstField := src.Field(i), dst.Field(i)
+			if src.Type().Field(i).PkgPath != "" {
+				if !c.opts.IncludeUnexported {
+					continue
+				}
+				srcField, dstField = exposeField(srcField), exposeField(dstField)
+			}
+			// Reset the shallow copy.
+			dstField.Set(reflect.Zero(dstField.Type()))
+			c.copyInto(dstField, srcField)
+		}
+
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		key := copyKey{ptr: src.Pointer(), typ: src.Type(), len: src.Len()}
+		if cp, ok := c.copies[key]; ok {
+			dst.Set(cp)
+			return
+		}
+		cp := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+		c.copies[key] = cp
+		for i := 0; i < src.Len(); i++ {
+			c.copyInto(cp.Index(i), src.Index(i))
+		}
+		dst.Set(cp)
+
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			c.copyInto(dst.Index(i), src.Index(i))
+		}
+
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		key := copyKey{ptr: src.Pointer(), typ: src.Type()}
+		if cp, ok := c.copies[key]; ok {
+			dst.Set(cp)
+			return
+		}
+		cp := reflect.MakeMapWithSize(src.Type(), src.Len())
+		c.copies[key] = cp
+
+		iter := src.MapRange()
+		for iter.Next() {
+			k := reflect.New(src.Type().Key()).Elem()
+			c.copyInto(k, iter.Key())
+			v := reflect.New(src.Type().Elem()).Elem()
+			c.copyInto(v, iter.Value())
+			cp.SetMapIndex(k, v)
+		}
+		dst.Set(cp)
+
+	default:
+		// Scalars, channels, functions and unsafe pointers.
+		dst.Set(src)
+	}
+}
+
+// DeepCopy returns an independent copy of the value.
+// Pointers, slices, maps, arrays, interfaces and structs are copied
+// recursively. Values referenced multiple times are copied once, so aliasing
+// and cycles are preserved.
+// Unexported struct fields are copied shallowly. See DeepCopyWith.
+func (r *Reflector) DeepCopy() *Reflector {
+	return r.DeepCopyWith(nil)
+}
+
+// DeepCopyWith returns an independent copy of the value, using the given
+// options. See DeepCopy.
+func (r *Reflector) DeepCopyWith(opts *CopyOptions) *Reflector {
+	if !r.IsValid() || !r.value.CanInterface() {
+		return nil
+	}
+	if opts == nil {
+		opts = &CopyOptions{}
+	}
+
+	c := &copier{
+		opts:   opts,
+		copies: make(map[copyKey]reflect.Value),
+	}
+	dst := reflect.New(r.Type()).Elem()
+	c.copyInto(dst, r.value)
+	return Reflect(dst)
+}
+
+// exposeField makes an unexported field of an addressable struct
+// accessible. Only used for CopyOptions.IncludeUnexported.
+func exposeField(v reflect.Value) reflect.Value {
+	if v.CanInterface() || !v.CanAddr() {
+		return v
+	}
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
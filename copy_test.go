@@ -0,0 +1,123 @@
+package reflector_test
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	. "github.com/theduke/go-reflector"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type copyNode struct {
+	Name string
+	Next *copyNode
+}
+
+type copyStruct struct {
+	Name     string
+	Tags     []string
+	Attrs    map[string]interface{}
+	Fixed    [2]*copyNode
+	Node     *copyNode
+	Alias    *copyNode
+	Any      interface{}
+	Created  time.Time
+	Callback func() int
+	lock     sync.Mutex
+	secret   *copyNode
+}
+
+var _ = Describe("DeepCopy", func() {
+	var s *copyStruct
+
+	BeforeEach(func() {
+		node := &copyNode{Name: "n"}
+		s = &copyStruct{
+			Name:     "s",
+			Tags:     []string{"a", "b"},
+			Attrs:    map[string]interface{}{"list": []interface{}{1, map[string]interface{}{"k": "v"}}},
+			Fixed:    [2]*copyNode{{Name: "f"}, nil},
+			Node:     node,
+			Alias:    node,
+			Any:      &copyNode{Name: "any"},
+			Created:  time.Date(2015, 11, 4, 0, 0, 0, 0, time.UTC),
+			Callback: func() int { return 1 },
+			secret:   &copyNode{Name: "secret"},
+		}
+	})
+
+	It("Should copy nested values", func() {
+		cp := R(s).DeepCopy().Interface().(*copyStruct)
+		Expect(cp).ToNot(BeIdenticalTo(s))
+		Expect(cp.Name).To(Equal("s"))
+		Expect(cp.Tags).To(Equal(s.Tags))
+		Expect(cp.Attrs).To(Equal(s.Attrs))
+		Expect(cp.Fixed[0]).To(Equal(s.Fixed[0]))
+		Expect(cp.Created).To(Equal(s.Created))
+		Expect(cp.Callback()).To(Equal(1))
+
+		cp.Tags[0] = "changed"
+		cp.Attrs["list"].([]interface{})[1].(map[string]interface{})["k"] = "changed"
+		cp.Fixed[0].Name = "changed"
+		cp.Node.Name = "changed"
+		cp.Any.(*copyNode).Name = "changed"
+
+		Expect(s.Tags[0]).To(Equal("a"))
+		Expect(s.Attrs["list"].([]interface{})[1]).To(Equal(map[string]interface{}{"k": "v"}))
+		Expect(s.Fixed[0].Name).To(Equal("f"))
+		Expect(s.Node.Name).To(Equal("n"))
+		Expect(s.Any.(*copyNode).Name).To(Equal("any"))
+	})
+
+	It("Should preserve aliasing", func() {
+		cp := R(s).DeepCopy().Interface().(*copyStruct)
+		Expect(cp.Node).ToNot(BeIdenticalTo(s.Node))
+		Expect(cp.Alias).To(BeIdenticalTo(cp.Node))
+	})
+
+	It("Should preserve cycles", func() {
+		a := &copyNode{Name: "a"}
+		b := &copyNode{Name: "b", Next: a}
+		a.Next = b
+
+		cp := R(a).DeepCopy().Interface().(*copyNode)
+		Expect(cp).ToNot(BeIdenticalTo(a))
+		Expect(cp.Next.Name).To(Equal("b"))
+		Expect(cp.Next.Next).To(BeIdenticalTo(cp))
+	})
+
+	It("Should copy non pointer values", func() {
+		m := map[string][]int{"a": {1}}
+		cp := R(m).DeepCopy().Interface().(map[string][]int)
+		cp["a"][0] = 2
+		Expect(m["a"][0]).To(Equal(1))
+
+		Expect(R(5).DeepCopy().Interface()).To(Equal(5))
+	})
+
+	It("Should copy unexported fields shallowly by default", func() {
+		cp := R(s).DeepCopy().Interface().(*copyStruct)
+		Expect(cp.secret).To(BeIdenticalTo(s.secret))
+	})
+
+	It("Should deep copy unexported fields", func() {
+		cp := R(s).DeepCopyWith(&CopyOptions{IncludeUnexported: true}).Interface().(*copyStruct)
+		Expect(cp.secret).ToNot(BeIdenticalTo(s.secret))
+		Expect(cp.secret.Name).To(Equal("secret"))
+	})
+
+	It("Should copy shallow types with an assignment", func() {
+		cp := R(s).DeepCopyWith(&CopyOptions{
+			ShallowTypes: []reflect.Type{reflect.TypeOf(&copyNode{})},
+		}).Interface().(*copyStruct)
+		Expect(cp).ToNot(BeIdenticalTo(s))
+		Expect(cp.Node).To(BeIdenticalTo(s.Node))
+	})
+
+	It("Should return nil for invalid values", func() {
+		Expect(R(nil).DeepCopy()).To(BeNil())
+	})
+})
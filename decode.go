@@ -0,0 +1,239 @@
+package reflector
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// decodeValue decodes raw, which is usually JSON-shaped data made of maps,
+// slices and scalar values, into target.
+// Types implementing encoding.TextUnmarshaler or json.Unmarshaler decode
+// raw themselves. Structs are loaded from maps with string keys, and
+// pointers, slices, arrays and maps are decoded recursively.
+// Everything else is assigned with the convert options in opts.
+func decodeValue(target reflect.Value, raw *Reflector, opts *MapOptions, depth int) error {
+	// De-reference pointers unless they can be used as they are.
+	for raw != nil && raw.IsPtr() && !raw.Type().AssignableTo(target.Type()) {
+		raw = raw.Elem()
+	}
+	if raw == nil || !raw.IsValid() || raw.Type().AssignableTo(target.Type()) {
+		return assignValue(target, raw, &opts.ConvertOptions)
+	}
+
+	if ok, err := unmarshalDecode(target, raw); ok {
+		return err
+	}
+
+	switch target.Kind() {
+	case reflect.Ptr:
+		return decodePtr(target, raw, opts, depth)
+
+	case reflect.Struct:
+		if target.Type() == timeType {
+			break
+		}
+		if data, ok := stringKeyMap(raw); ok {
+			if opts.depthExceeded(depth) {
+				return nil
+			}
+			s, err := Reflect(target).Struct()
+			if err != nil {
+				return err
+			}
+			return s.fromMap(data, opts, depth+1)
+		}
+
+	case reflect.Slice:
+		if raw.IsSlice() || raw.IsArray() {
+			return decodeSlice(target, raw, opts, depth)
+		}
+
+	case reflect.Array:
+		if raw.IsSlice() || raw.IsArray() {
+			return decodeArray(target, raw, opts, depth)
+		}
+
+	case reflect.Map:
+		if raw.IsMap() {
+			return decodeMap(target, raw, opts, depth)
+		}
+	}
+
+	return assignValue(target, raw, &opts.ConvertOptions)
+}
+
+// decodePtr decodes into the value target points to.
+// Nil pointers are only set if decoding succeeded, or in strict mode, where
+// partial results are kept.
+func decodePtr(target reflect.Value, raw *Reflector, opts *MapOptions, depth int) error {
+	if !target.IsNil() {
+		return decodeValue(target.Elem(), raw, opts, depth)
+	}
+	if !target.CanSet() {
+		return newError(ERR_NIL_POINTER)
+	}
+
+	ptr := reflect.New(target.Type().Elem())
+	err := decodeValue(ptr.Elem(), raw, opts, depth)
+	if err != nil && !opts.Strict {
+		return err
+	}
+	target.Set(ptr)
+	return err
+}
+
+// decodeSlice builds a new slice with the decoded items of raw, and
+// assigns it to target.
+func decodeSlice(target reflect.Value, raw *Reflector, opts *MapOptions, depth int) error {
+	slice, err := New(target.Type()).Slice()
+	if err != nil {
+		return err
+	}
+
+	errs := &errorList{collect: opts.Strict}
+	for i := 0; i < raw.Len(); i++ {
+		item := reflect.New(target.Type().Elem()).Elem()
+		if err := decodeValue(item, Reflect(raw.Value().Index(i)), opts, depth); err != nil {
+			if err := errs.add(prefixPath(err, indexPath(i))); err != nil {
+				return err
+			}
+		}
+		// Not using Reflect(), which would de-reference interfaces.
+		if err := slice.Append(&Reflector{value: item}); err != nil {
+			return prefixPath(err, indexPath(i))
+		}
+	}
+
+	if err := assignValue(target, Reflect(slice.Interface()), nil); err != nil {
+		return err
+	}
+	return errs.err()
+}
+
+// decodeArray decodes the items of raw into the array target.
+// The lengths must match.
+func decodeArray(target reflect.Value, raw *Reflector, opts *MapOptions, depth int) error {
+	if raw.Len() != target.Len() {
+		return &Error{
+			Code:    ERR_INDEX_OUT_OF_BOUNDS,
+			Source:  raw.Type(),
+			Target:  target.Type(),
+			Message: fmt.Sprintf("expected %v items, got %v", target.Len(), raw.Len()),
+		}
+	}
+
+	errs := &errorList{collect: opts.Strict}
+	for i := 0; i < raw.Len(); i++ {
+		if err := decodeValue(target.Index(i), Reflect(raw.Value().Index(i)), opts, depth); err != nil {
+			if err := errs.add(prefixPath(err, indexPath(i))); err != nil {
+				return err
+			}
+		}
+	}
+	return errs.err()
+}
+
+// decodeMap decodes the entries of raw into the map target.
+// Keys are always converted to the key type, and values are decoded into
+// existing entries, so nested structs are updated like struct fields.
+// Nil maps are allocated.
+func decodeMap(target reflect.Value, raw *Reflector, opts *MapOptions, depth int) error {
+	m := target
+	if m.IsNil() {
+		if !target.CanSet() {
+			return newError(ERR_NIL_MAP)
+		}
+		m = reflect.MakeMap(target.Type())
+	}
+	mapR := Reflect(m)
+
+	source, err := raw.Map()
+	if err != nil {
+		return err
+	}
+
+	keyType := target.Type().Key()
+	keyOpts := opts.ConvertOptions.lenient()
+
+	errs := &errorList{collect: opts.Strict}
+	for _, entry := range source.SortedEntries() {
+		path := fmt.Sprint(entry.Key.Interface())
+
+		key := entry.Key
+		if !key.Type().AssignableTo(keyType) {
+			converted, err := key.ConvertToTypeWith(keyType, keyOpts)
+			if err != nil {
+				if err := errs.add(prefixPath(err, path)); err != nil {
+					return err
+				}
+				continue
+			}
+			key = Reflect(reflect.ValueOf(converted).Convert(keyType))
+		}
+
+		item := reflect.New(target.Type().Elem()).Elem()
+		if existing := m.MapIndex(key.Value()); existing.IsValid() {
+			item.Set(existing)
+		}
+		if err := decodeValue(item, entry.Value, opts, depth); err != nil {
+			if err := errs.add(prefixPath(err, path)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := mapR.SetMapKey(key, &Reflector{value: item}); err != nil {
+			return prefixPath(err, path)
+		}
+	}
+
+	if target.IsNil() {
+		target.Set(m)
+	}
+	return errs.err()
+}
+
+// stringKeyMap returns the entries of raw, if it is a map with string keys.
+func stringKeyMap(raw *Reflector) (map[string]interface{}, bool) {
+	if data, ok := raw.Interface().(map[string]interface{}); ok {
+		return data, true
+	}
+	if !raw.IsMap() || raw.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+
+	data := make(map[string]interface{}, raw.Len())
+	iter := raw.Value().MapRange()
+	for iter.Next() {
+		data[iter.Key().String()] = iter.Value().Interface()
+	}
+	return data, true
+}
+
+// Decode decodes data, which is usually JSON-shaped data made of maps,
+// slices and scalar values, into the value, which must be a pointer.
+// Maps are loaded into structs like with FromMap, and slices, arrays, maps
+// and pointers are decoded recursively.
+// If convert is true, values are converted to the target types.
+func (r *Reflector) Decode(data interface{}, convert ...bool) error {
+	return r.DecodeWith(data, &MapOptions{
+		ConvertOptions: ConvertOptions{Mode: convertMode(convert)},
+	})
+}
+
+// DecodeWith decodes data into the value, using the given options.
+// See Decode for details.
+func (r *Reflector) DecodeWith(data interface{}, opts *MapOptions) error {
+	if opts == nil {
+		opts = &MapOptions{}
+	}
+
+	target := r.value
+	if !target.CanSet() {
+		if !r.IsPtr() || r.IsNil() {
+			return &Error{Code: ERR_UNSETTABLE_VALUE, Target: r.Type()}
+		}
+		target = target.Elem()
+	}
+	return decodeValue(target, Reflect(data), opts, 0)
+}
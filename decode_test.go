@@ -0,0 +1,148 @@
+package reflector_test
+
+import (
+	"errors"
+
+	. "github.com/theduke/go-reflector"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type decodeItem struct {
+	Name  string
+	Count int
+}
+
+type decodeStruct struct {
+	Items    []decodeItem
+	ItemPtrs []*decodeItem
+	ByName   map[string]decodeItem
+	ByID     map[int]*decodeItem
+	Fixed    [2]decodeItem
+	Matrix   [][]int
+	ListPtr  *[]decodeItem
+	Any      []interface{}
+}
+
+var _ = Describe("Decode", func() {
+	var data map[string]interface{}
+
+	BeforeEach(func() {
+		data = map[string]interface{}{
+			"Items": []interface{}{
+				map[string]interface{}{"Name": "a", "Count": 1},
+				map[string]interface{}{"Name": "b"},
+			},
+			"ItemPtrs": []interface{}{
+				map[string]interface{}{"Name": "p"},
+				nil,
+			},
+			"ByName": map[string]interface{}{
+				"x": map[string]interface{}{"Name": "x", "Count": 3},
+			},
+			"ByID": map[string]interface{}{
+				"7": map[string]interface{}{"Name": "seven"},
+			},
+			"Fixed": []interface{}{
+				map[string]interface{}{"Name": "f1"},
+				map[string]interface{}{"Name": "f2"},
+			},
+			"Matrix":  []interface{}{[]interface{}{1, 2}, []interface{}{3}},
+			"ListPtr": []interface{}{map[string]interface{}{"Name": "l"}},
+			"Any":     []interface{}{1, "a", nil},
+		}
+	})
+
+	It("Should decode nested slices, maps and arrays in FromMap", func() {
+		s := &decodeStruct{}
+		Expect(R(s).MustStruct().FromMap(data)).ToNot(HaveOccurred())
+
+		Expect(s.Items).To(Equal([]decodeItem{{"a", 1}, {"b", 0}}))
+		Expect(s.ItemPtrs).To(Equal([]*decodeItem{{Name: "p"}, nil}))
+		Expect(s.ByName).To(Equal(map[string]decodeItem{"x": {"x", 3}}))
+		Expect(s.ByID).To(Equal(map[int]*decodeItem{7: {Name: "seven"}}))
+		Expect(s.Fixed).To(Equal([2]decodeItem{{Name: "f1"}, {Name: "f2"}}))
+		Expect(s.Matrix).To(Equal([][]int{{1, 2}, {3}}))
+		Expect(*s.ListPtr).To(Equal([]decodeItem{{Name: "l"}}))
+		Expect(s.Any).To(Equal([]interface{}{1, "a", nil}))
+	})
+
+	It("Should update existing map entries", func() {
+		s := &decodeStruct{ByName: map[string]decodeItem{
+			"x": {Name: "old", Count: 1},
+			"y": {Name: "y"},
+		}}
+		err := R(s).MustStruct().FromMap(map[string]interface{}{
+			"ByName": map[string]interface{}{"x": map[string]interface{}{"Name": "new"}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.ByName).To(Equal(map[string]decodeItem{
+			"x": {Name: "new", Count: 1},
+			"y": {Name: "y"},
+		}))
+	})
+
+	It("Should report the path of failing items", func() {
+		s := &decodeStruct{}
+		err := R(s).MustStruct().FromMap(map[string]interface{}{
+			"Items": []interface{}{
+				map[string]interface{}{"Name": "a"},
+				map[string]interface{}{"Count": "x"},
+			},
+		})
+		Expect(errors.Is(err, ErrTypeMismatch)).To(BeTrue())
+		Expect(err.(*Error).Path).To(Equal("Items[1].Count"))
+	})
+
+	It("Should convert item values when enabled", func() {
+		s := &decodeStruct{}
+		err := R(s).MustStruct().FromMap(map[string]interface{}{
+			"Matrix": []interface{}{[]interface{}{"1", 2.0}},
+		}, true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.Matrix).To(Equal([][]int{{1, 2}}))
+	})
+
+	It("Should collect item errors in strict mode", func() {
+		s := &decodeStruct{}
+		err := R(s).MustStruct().FromMapWith(map[string]interface{}{
+			"Items": []interface{}{
+				map[string]interface{}{"Count": "x"},
+				map[string]interface{}{"Nope": 1},
+			},
+		}, &MapOptions{Strict: true})
+
+		var multi *MultiError
+		Expect(errors.As(err, &multi)).To(BeTrue())
+		Expect(multi.Errors).To(HaveLen(2))
+		Expect(multi.Errors[0].Path).To(Equal("Items[0].Count"))
+		Expect(multi.Errors[1].Path).To(Equal("Items[1].Nope"))
+	})
+
+	It("Should reject arrays of the wrong length", func() {
+		s := &decodeStruct{}
+		err := R(s).MustStruct().FromMap(map[string]interface{}{
+			"Fixed": []interface{}{map[string]interface{}{"Name": "f1"}},
+		})
+		Expect(errors.Is(err, ErrIndexOutOfBounds)).To(BeTrue())
+	})
+
+	It("Should decode into arbitrary values", func() {
+		var items []*decodeItem
+		err := R(&items).Decode([]interface{}{
+			map[string]interface{}{"Name": "a"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(items).To(Equal([]*decodeItem{{Name: "a"}}))
+
+		var s decodeStruct
+		Expect(R(&s).Decode(data)).ToNot(HaveOccurred())
+		Expect(s.ByName["x"].Count).To(Equal(3))
+	})
+
+	It("Should fail to decode into unaddressable values", func() {
+		err := R(decodeItem{}).Decode(map[string]interface{}{"Name": "a"})
+		Expect(errors.Is(err, ErrUnsettableValue)).To(BeTrue())
+	})
+})
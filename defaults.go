@@ -0,0 +1,166 @@
+package reflector
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// defaultTag is the struct tag that holds default values.
+const defaultTag = "default"
+
+// hasDefaults returns true if the struct type typ, or any nested struct,
+// has fields with a default tag.
+func hasDefaults(typ reflect.Type, seen map[reflect.Type]bool) bool {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct || typ == timeType || seen[typ] {
+		return false
+	}
+	seen[typ] = true
+
+	for i := 0; i < typ.NumField(); i++ {
+		info := typ.Field(i)
+		if info.PkgPath != "" && !info.Anonymous {
+			continue
+		}
+		if _, ok := info.Tag.Lookup(defaultTag); ok || hasDefaults(info.Type, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDefault parses a default tag into a value of type typ.
+// Slices are separated by commas, and maps use the format "k:v,k2:v2".
+func parseDefault(tag string, typ reflect.Type, opts *ConvertOptions) (reflect.Value, error) {
+	switch {
+	case typ.Kind() == reflect.Ptr:
+		elem, err := parseDefault(tag, typ.Elem(), opts)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr := reflect.New(typ.Elem())
+		ptr.Elem().Set(elem)
+		return ptr, nil
+
+	case typ.Kind() == reflect.Slice && typ.Elem().Kind() != reflect.Uint8:
+		items := strings.Split(tag, ",")
+		slice := reflect.MakeSlice(typ, len(items), len(items))
+		for i, item := range items {
+			value, err := parseDefault(strings.TrimSpace(item), typ.Elem(), opts)
+			if err != nil {
+				return reflect.Value{}, prefixPath(err, indexPath(i))
+			}
+			slice.Index(i).Set(value)
+		}
+		return slice, nil
+
+	case typ.Kind() == reflect.Map:
+		m := reflect.MakeMap(typ)
+		for _, entry := range strings.Split(tag, ",") {
+			parts := strings.SplitN(entry, ":", 2)
+			if len(parts) != 2 {
+				return reflect.Value{}, &Error{
+					Code:    ERR_INVALID_FIELD,
+					Target:  typ,
+					Message: "map defaults must have the format k:v,k2:v2",
+				}
+			}
+			key, err := parseDefault(strings.TrimSpace(parts[0]), typ.Key(), opts)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			value, err := parseDefault(strings.TrimSpace(parts[1]), typ.Elem(), opts)
+			if err != nil {
+				return reflect.Value{}, prefixPath(err, parts[0])
+			}
+			m.SetMapIndex(key, value)
+		}
+		return m, nil
+
+	case typ.Kind() == reflect.Bool:
+		// Accept the Go syntax, which ConvertToType doesn't.
+		if b, err := strconv.ParseBool(tag); err == nil {
+			return reflect.ValueOf(b).Convert(typ), nil
+		}
+	}
+
+	// Defaults that don't fit into the type, like 300 for uint8, are errors.
+	strict := opts.lenient()
+	strict.Mode = ConvertStrict
+	value, err := Reflect(tag).ConvertToTypeWith(typ, strict)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return reflect.ValueOf(value).Convert(typ), nil
+}
+
+type defaulter struct {
+	opts *ConvertOptions
+}
+
+// active returns true if a value of type typ is a parent of node, to avoid
+// allocating recursive types endlessly.
+func active(node *WalkNode, typ reflect.Type) bool {
+	for parent := node.Parent; parent != nil; parent = parent.Parent {
+		parentType := parent.Value.Type()
+		if parentType.Kind() == reflect.Ptr {
+			parentType = parentType.Elem()
+		}
+		if parentType == typ {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *defaulter) visit(node *WalkNode) error {
+	if node.Field == nil || !node.slot.IsZero() {
+		return nil
+	}
+
+	tag, ok := node.Field.Tag.Lookup(defaultTag)
+	if tag == "-" {
+		return ErrSkipNode
+	}
+	if ok {
+		value, err := parseDefault(tag, node.slot.Type(), d.opts)
+		if err != nil {
+			return prefixPath(err, node.Path)
+		}
+		return node.Replace(value)
+	}
+
+	// Allocate nil struct pointers if the struct has defaults.
+	typ := node.slot.Type()
+	if typ.Kind() == reflect.Ptr && hasDefaults(typ.Elem(), make(map[reflect.Type]bool)) && !active(node, typ.Elem()) {
+		return node.Replace(reflect.New(typ.Elem()))
+	}
+	return nil
+}
+
+// ApplyDefaults sets zero fields to the value in their `default` tag, like
+// `default:"8080"`. The tag is converted to the field type like with
+// ConvertStrict, so defaults that overflow the type are errors. Slices are
+// separated by commas and maps use the format `default:"k:v,k2:v2"`.
+// Nested structs are handled recursively, and nil struct pointers are
+// allocated if the struct has defaults. Fields tagged with `default:"-"` are
+// skipped.
+// The struct must be addressable.
+func (r *StructReflector) ApplyDefaults() error {
+	return r.ApplyDefaultsWith(nil)
+}
+
+// ApplyDefaultsWith sets zero fields to their defaults, converting the tags
+// according to the given options, for example to parse times with custom
+// layouts.
+// See ApplyDefaults.
+func (r *StructReflector) ApplyDefaultsWith(opts *ConvertOptions) error {
+	if !r.structItem.Value().CanSet() {
+		return &Error{Code: ERR_UNSETTABLE_VALUE, Target: r.Type()}
+	}
+	d := &defaulter{opts: opts}
+	return Walk(r.structItem, d.visit)
+}
@@ -0,0 +1,122 @@
+package reflector_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/theduke/go-reflector"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type defaultsTLS struct {
+	Enabled bool   `default:"true"`
+	Cert    string `default:"cert.pem"`
+}
+
+type defaultsNode struct {
+	Weight int `default:"1"`
+	Next   *defaultsNode
+}
+
+type defaultsServer struct {
+	Host     string         `default:"localhost"`
+	Port     int            `default:"8080"`
+	Ratio    *float64       `default:"0.5"`
+	Timeout  time.Duration  `default:"30s"`
+	Started  time.Time      `default:"2015-11-04T10:30:00Z"`
+	Origins  []string       `default:"a.com, b.com"`
+	Ports    []int          `default:"80,443"`
+	Limits   map[string]int `default:"read:10,write:5"`
+	TLS      *defaultsTLS
+	Backup   defaultsTLS
+	Nodes    []defaultsNode
+	Root     *defaultsNode
+	Labels   map[string]string
+	Disabled *defaultsTLS `default:"-"`
+}
+
+var _ = Describe("ApplyDefaults", func() {
+	It("Should set zero fields", func() {
+		s := &defaultsServer{
+			Port:  9000,
+			Nodes: []defaultsNode{{}, {Weight: 5}},
+		}
+		Expect(R(s).MustStruct().ApplyDefaults()).To(Succeed())
+
+		Expect(s.Host).To(Equal("localhost"))
+		Expect(s.Port).To(Equal(9000))
+		Expect(*s.Ratio).To(Equal(0.5))
+		Expect(s.Timeout).To(Equal(30 * time.Second))
+		Expect(s.Started).To(Equal(time.Date(2015, 11, 4, 10, 30, 0, 0, time.UTC)))
+		Expect(s.Origins).To(Equal([]string{"a.com", "b.com"}))
+		Expect(s.Ports).To(Equal([]int{80, 443}))
+		Expect(s.Limits).To(Equal(map[string]int{"read": 10, "write": 5}))
+		Expect(s.Labels).To(BeNil())
+		Expect(s.Disabled).To(BeNil())
+	})
+
+	It("Should parse bool defaults", func() {
+		s := &struct {
+			A bool  `default:"True"`
+			B *bool `default:"false"`
+			C bool  `default:"yes"`
+		}{}
+		Expect(R(s).MustStruct().ApplyDefaults()).To(Succeed())
+		Expect(s.A).To(BeTrue())
+		Expect(*s.B).To(BeFalse())
+		Expect(s.C).To(BeTrue())
+	})
+
+	It("Should recurse into nested structs", func() {
+		s := &defaultsServer{
+			Nodes: []defaultsNode{{}, {Weight: 5}},
+		}
+		Expect(R(s).MustStruct().ApplyDefaults()).To(Succeed())
+
+		Expect(s.TLS).To(Equal(&defaultsTLS{Enabled: true, Cert: "cert.pem"}))
+		Expect(s.Backup).To(Equal(defaultsTLS{Enabled: true, Cert: "cert.pem"}))
+		Expect(s.Nodes[0].Weight).To(Equal(1))
+		Expect(s.Nodes[1].Weight).To(Equal(5))
+
+		// Recursive types are allocated once.
+		Expect(s.Root).To(Equal(&defaultsNode{Weight: 1}))
+	})
+
+	It("Should use the conversion options", func() {
+		s := &struct {
+			Day time.Time `default:"2015-11-04"`
+		}{}
+		Expect(R(s).MustStruct().ApplyDefaultsWith(&ConvertOptions{
+			TimeLayouts: []string{DateLayout},
+		})).To(Succeed())
+		Expect(s.Day).To(Equal(time.Date(2015, 11, 4, 0, 0, 0, 0, time.UTC)))
+	})
+
+	It("Should report invalid defaults", func() {
+		s := &struct {
+			Nested struct {
+				Port int `default:"http"`
+			}
+		}{}
+		err := R(s).MustStruct().ApplyDefaults()
+		Expect(errors.Is(err, ErrUnconvertableTypes)).To(BeTrue())
+		Expect(err.(*Error).Path).To(Equal("Nested.Port"))
+
+		m := &struct {
+			Limits map[string]int `default:"read"`
+		}{}
+		Expect(errors.Is(R(m).MustStruct().ApplyDefaults(), ErrInvalidField)).To(BeTrue())
+
+		o := &struct {
+			U uint8 `default:"300"`
+		}{}
+		err = R(o).MustStruct().ApplyDefaults()
+		Expect(err).To(HaveOccurred())
+		Expect(err.(*Error).Path).To(Equal("U"))
+		Expect(o.U).To(BeZero())
+
+		Expect(errors.Is(R(defaultsTLS{}).MustStruct().ApplyDefaults(), ErrUnsettableValue)).To(BeTrue())
+	})
+})
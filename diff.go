@@ -0,0 +1,378 @@
+package reflector
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// ChangeKind describes how a value changed.
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeRemoved  ChangeKind = "removed"
+	ChangeModified ChangeKind = "modified"
+)
+
+// Change is a single difference found by Diff.
+type Change struct {
+	// Path is the location of the change, like "Address.Lines[2]".
+	// Slice items matched with DiffOptions.KeyFields use paths like
+	// "Items[ID=3]". Empty for the root value.
+	Path string
+	Kind ChangeKind
+	// Old is nil for added values.
+	Old interface{}
+	// New is nil for removed values.
+	New interface{}
+}
+
+func (c *Change) String() string {
+	return fmt.Sprintf("%v %v: %v => %v", c.Kind, c.Path, c.Old, c.New)
+}
+
+// DiffOptions control DiffWith.
+// A nil *DiffOptions is equivalent to the zero value.
+type DiffOptions struct {
+	// IgnoreFields are skipped. Entries may be field names, which are
+	// ignored in all structs, or full paths like "Address.Zip".
+	IgnoreFields []string
+
+	// IgnoreUnexported skips unexported struct fields.
+	IgnoreUnexported bool
+
+	// SliceAsSet compares slices and arrays ignoring the order of items.
+	// Items that are only present in one of them are reported as added or
+	// removed, with the index in the respective slice.
+	SliceAsSet bool
+
+	// KeyFields maps struct types to the name of a field that identifies
+	// items in slices of that struct type, or of pointers to it.
+	// Items with the same key are compared with each other, regardless of
+	// their position.
+	KeyFields map[reflect.Type]string
+}
+
+func (o *DiffOptions) ignored(path, field string) bool {
+	for _, name := range o.IgnoreFields {
+		if name == field || name == path {
+			return true
+		}
+	}
+	return false
+}
+
+type diffVisit struct {
+	a, b uintptr
+	typ  reflect.Type
+}
+
+type differ struct {
+	opts    *DiffOptions
+	changes []*Change
+	// visited holds pointer pairs on the current path, to stop at cycles.
+	visited map[diffVisit]bool
+}
+
+func (d *differ) add(path string, kind ChangeKind, a, b reflect.Value) {
+	change := &Change{Path: path, Kind: kind}
+	if a.IsValid() {
+		change.Old = readable(a).Interface()
+	}
+	if b.IsValid() {
+		change.New = readable(b).Interface()
+	}
+	d.changes = append(d.changes, change)
+}
+
+// equal returns true if diffing a and b yields no changes.
+func (d *differ) equal(a, b reflect.Value) bool {
+	sub := &differ{opts: d.opts, visited: d.visited}
+	sub.diff("", a, b)
+	return len(sub.changes) == 0
+}
+
+// leafEqual compares values that are not traversed.
+// Values of the same type are compared with Equals, all others, and
+// time.Time values, with CompareTo, so 1 and 1.0 are equal.
+func leafEqual(a, b reflect.Value) bool {
+	a, b = readable(a), readable(b)
+	if !a.CanInterface() || !b.CanInterface() {
+		return false
+	}
+	if a.Type() == b.Type() && a.Type() != timeType {
+		return Reflect(a).Equals(b)
+	}
+	eq, err := Reflect(a).CompareTo(Reflect(b), "=")
+	return err == nil && eq
+}
+
+func (d *differ) diff(path string, a, b reflect.Value) {
+	// De-reference interfaces.
+	for a.IsValid() && a.Kind() == reflect.Interface && !a.IsNil() {
+		a = a.Elem()
+	}
+	for b.IsValid() && b.Kind() == reflect.Interface && !b.IsNil() {
+		b = b.Elem()
+	}
+	aNil := !a.IsValid() || a.Kind() == reflect.Interface
+	bNil := !b.IsValid() || b.Kind() == reflect.Interface
+	if aNil || bNil {
+		if aNil != bNil {
+			d.add(path, ChangeModified, a, b)
+		}
+		return
+	}
+
+	if a.Type() != b.Type() {
+		if !leafEqual(a, b) {
+			d.add(path, ChangeModified, a, b)
+		}
+		return
+	}
+
+	switch a.Kind() {
+	case reflect.Ptr:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				d.add(path, ChangeModified, a, b)
+			}
+			return
+		}
+		if a.Pointer() == b.Pointer() {
+			return
+		}
+		visit := diffVisit{a.Pointer(), b.Pointer(), a.Type()}
+		if d.visited[visit] {
+			return
+		}
+		d.visited[visit] = true
+		defer delete(d.visited, visit)
+		d.diff(path, a.Elem(), b.Elem())
+
+	case reflect.Struct:
+		// Unexported values are compared field by field, since they can
+		// only be copied partially.
+		leaf := a.Type() == timeType || isMarshaler(a.Type())
+		if leaf && a.CanInterface() && b.CanInterface() {
+			if !leafEqual(a, b) {
+				d.add(path, ChangeModified, a, b)
+			}
+			return
+		}
+		d.diffStruct(path, a, b)
+
+	case reflect.Map:
+		d.diffMap(path, a, b)
+
+	case reflect.Slice, reflect.Array:
+		if d.opts.SliceAsSet {
+			d.diffSet(path, a, b)
+		} else if field, ok := d.keyField(a.Type().Elem()); ok {
+			d.diffKeyed(path, field, a, b)
+		} else {
+			d.diffSlice(path, a, b)
+		}
+
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		if a.Pointer() != b.Pointer() {
+			d.add(path, ChangeModified, a, b)
+		}
+
+	default:
+		if !leafEqual(a, b) {
+			d.add(path, ChangeModified, a, b)
+		}
+	}
+}
+
+func (d *differ) diffStruct(path string, a, b reflect.Value) {
+	typ := a.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		info := typ.Field(i)
+		fieldPath := joinPath(path, info.Name)
+		if d.opts.ignored(fieldPath, info.Name) {
+			continue
+		}
+
+		fieldA, fieldB := a.Field(i), b.Field(i)
+		if info.PkgPath != "" {
+			if d.opts.IgnoreUnexported {
+				continue
+			}
+		}
+		d.diff(fieldPath, fieldA, fieldB)
+	}
+}
+
+func (d *differ) diffMap(path string, a, b reflect.Value) {
+	keys := make([]*Reflector, 0, a.Len()+b.Len())
+	for _, key := range a.MapKeys() {
+		keys = append(keys, Reflect(readable(key)))
+	}
+	for _, key := range b.MapKeys() {
+		if !a.MapIndex(key).IsValid() {
+			keys = append(keys, Reflect(readable(key)))
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return lessMapKey(keys[i], keys[j])
+	})
+
+	for _, key := range keys {
+		keyPath := joinPath(path, mapKeyString(key.Value()))
+		valA, valB := a.MapIndex(key.Value()), b.MapIndex(key.Value())
+		switch {
+		case !valB.IsValid():
+			d.add(keyPath, ChangeRemoved, valA, valB)
+		case !valA.IsValid():
+			d.add(keyPath, ChangeAdded, valA, valB)
+		default:
+			d.diff(keyPath, valA, valB)
+		}
+	}
+}
+
+func (d *differ) diffSlice(path string, a, b reflect.Value) {
+	for i := 0; i < a.Len() || i < b.Len(); i++ {
+		itemPath := joinPath(path, indexPath(i))
+		switch {
+		case i >= b.Len():
+			d.add(itemPath, ChangeRemoved, a.Index(i), reflect.Value{})
+		case i >= a.Len():
+			d.add(itemPath, ChangeAdded, reflect.Value{}, b.Index(i))
+		default:
+			d.diff(itemPath, a.Index(i), b.Index(i))
+		}
+	}
+}
+
+// diffSet reports items without an equal item in the other slice.
+func (d *differ) diffSet(path string, a, b reflect.Value) {
+	matched := make([]bool, b.Len())
+	for i := 0; i < a.Len(); i++ {
+		found := false
+		for j := 0; j < b.Len(); j++ {
+			if !matched[j] && d.equal(a.Index(i), b.Index(j)) {
+				matched[j] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			d.add(joinPath(path, indexPath(i)), ChangeRemoved, a.Index(i), reflect.Value{})
+		}
+	}
+	for j := 0; j < b.Len(); j++ {
+		if !matched[j] {
+			d.add(joinPath(path, indexPath(j)), ChangeAdded, reflect.Value{}, b.Index(j))
+		}
+	}
+}
+
+// keyField returns the key field configured for slice items of type typ.
+func (d *differ) keyField(typ reflect.Type) (string, bool) {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	field, ok := d.opts.KeyFields[typ]
+	return field, ok
+}
+
+// itemKey returns the value of the key field of a slice item.
+// ok is false for nil pointers.
+func itemKey(item reflect.Value, field string) (key interface{}, ok bool) {
+	if item.Kind() == reflect.Ptr {
+		if item.IsNil() {
+			return nil, false
+		}
+		item = item.Elem()
+	}
+	return readable(item.FieldByName(field)).Interface(), true
+}
+
+// diffKeyed compares slice items with the same key field value.
+func (d *differ) diffKeyed(path, field string, a, b reflect.Value) {
+	itemPath := func(key interface{}) string {
+		return fmt.Sprintf("%v[%v=%v]", path, field, key)
+	}
+
+	indexB := make(map[interface{}]int, b.Len())
+	for j := 0; j < b.Len(); j++ {
+		if key, ok := itemKey(b.Index(j), field); ok {
+			indexB[key] = j
+		}
+	}
+
+	seen := make(map[interface{}]bool, a.Len())
+	for i := 0; i < a.Len(); i++ {
+		key, ok := itemKey(a.Index(i), field)
+		if !ok {
+			continue
+		}
+		seen[key] = true
+		if j, ok := indexB[key]; ok {
+			d.diff(itemPath(key), a.Index(i), b.Index(j))
+		} else {
+			d.add(itemPath(key), ChangeRemoved, a.Index(i), reflect.Value{})
+		}
+	}
+	for j := 0; j < b.Len(); j++ {
+		if key, ok := itemKey(b.Index(j), field); ok && !seen[key] {
+			d.add(itemPath(key), ChangeAdded, reflect.Value{}, b.Index(j))
+		}
+	}
+}
+
+// Diff returns the changes needed to turn a into b.
+// Structs, maps, slices, arrays, pointers and interfaces are compared
+// recursively. Other values are compared with Equals if they have the same
+// type, and with CompareTo otherwise.
+func Diff(a, b interface{}) ([]*Change, error) {
+	return DiffWith(a, b, nil)
+}
+
+// DiffWith returns the changes needed to turn a into b, using the given
+// options. See Diff.
+func DiffWith(a, b interface{}, opts *DiffOptions) ([]*Change, error) {
+	if opts == nil {
+		opts = &DiffOptions{}
+	}
+	for typ, field := range opts.KeyFields {
+		if typ.Kind() != reflect.Struct {
+			return nil, &Error{Code: ERR_NOT_A_STRUCT, Target: typ}
+		}
+		if info, ok := typ.FieldByName(field); !ok || info.PkgPath != "" || !info.Type.Comparable() {
+			return nil, &Error{
+				Code:    ERR_INVALID_FIELD,
+				Path:    field,
+				Target:  typ,
+				Message: "key fields must be exported and comparable",
+			}
+		}
+	}
+
+	d := &differ{
+		opts:    opts,
+		changes: make([]*Change, 0),
+		visited: make(map[diffVisit]bool),
+	}
+	d.diff("", reflectValue(a), reflectValue(b))
+	return d.changes, nil
+}
+
+// reflectValue returns the reflect.Value for a value that may also be a
+// *Reflector or reflect.Value.
+func reflectValue(value interface{}) reflect.Value {
+	switch v := value.(type) {
+	case *Reflector:
+		if v == nil {
+			return reflect.Value{}
+		}
+		return v.Value()
+	case reflect.Value:
+		return v
+	}
+	return reflect.ValueOf(value)
+}
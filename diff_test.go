@@ -0,0 +1,172 @@
+package reflector_test
+
+import (
+	"errors"
+	"reflect"
+	"time"
+
+	. "github.com/theduke/go-reflector"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type diffLine struct {
+	ID    int
+	Title string
+}
+
+type diffStruct struct {
+	Name    string
+	Age     int
+	Tags    []string
+	Attrs   map[string]interface{}
+	Lines   []*diffLine
+	Parent  *diffStruct
+	Updated time.Time
+	version int
+}
+
+var _ = Describe("Diff", func() {
+	var a, b *diffStruct
+
+	BeforeEach(func() {
+		a = &diffStruct{
+			Name:    "a",
+			Age:     1,
+			Tags:    []string{"x", "y"},
+			Attrs:   map[string]interface{}{"color": "red", "size": 1, "old": true},
+			Lines:   []*diffLine{{1, "one"}, {2, "two"}},
+			Updated: time.Date(2015, 11, 4, 0, 0, 0, 0, time.UTC),
+			version: 1,
+		}
+		b = R(a).DeepCopy().Interface().(*diffStruct)
+	})
+
+	It("Should find no changes in equal values", func() {
+		Expect(Diff(a, b)).To(BeEmpty())
+		Expect(Diff(5, 5)).To(BeEmpty())
+		Expect(Diff(nil, nil)).To(BeEmpty())
+	})
+
+	It("Should report modified fields", func() {
+		b.Name = "b"
+		b.Updated = b.Updated.Add(time.Hour)
+		b.version = 2
+
+		Expect(Diff(a, b)).To(Equal([]*Change{
+			{Path: "Name", Kind: ChangeModified, Old: "a", New: "b"},
+			{Path: "Updated", Kind: ChangeModified, Old: a.Updated, New: b.Updated},
+			{Path: "version", Kind: ChangeModified, Old: 1, New: 2},
+		}))
+	})
+
+	It("Should compare times by instant", func() {
+		b.Updated = a.Updated.In(time.FixedZone("x", 3600))
+		Expect(Diff(a, b)).To(BeEmpty())
+	})
+
+	It("Should compare unexported values", func() {
+		type hidden struct {
+			line    *diffLine
+			created time.Time
+		}
+		now := time.Now()
+		x := hidden{&diffLine{ID: 1}, now}
+		Expect(Diff(x, hidden{&diffLine{ID: 1}, now})).To(BeEmpty())
+
+		changes, err := Diff(x, hidden{&diffLine{ID: 2}, now.Add(time.Hour)})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changes).ToNot(BeEmpty())
+		Expect(changes[0]).To(Equal(&Change{Path: "line.ID", Kind: ChangeModified, Old: 1, New: 2}))
+	})
+
+	It("Should report map and slice changes", func() {
+		b.Tags = append(b.Tags, "z")
+		delete(b.Attrs, "old")
+		b.Attrs["size"] = 2.0
+		b.Attrs["new"] = "yes"
+		b.Lines[1].Title = "zwei"
+
+		Expect(Diff(a, b)).To(Equal([]*Change{
+			{Path: "Tags[2]", Kind: ChangeAdded, New: "z"},
+			{Path: "Attrs.new", Kind: ChangeAdded, New: "yes"},
+			{Path: "Attrs.old", Kind: ChangeRemoved, Old: true},
+			{Path: "Attrs.size", Kind: ChangeModified, Old: 1, New: 2.0},
+			{Path: "Lines[1].Title", Kind: ChangeModified, Old: "two", New: "zwei"},
+		}))
+	})
+
+	It("Should compare numbers of different types by value", func() {
+		b.Attrs["size"] = 1.0
+		Expect(Diff(a, b)).To(BeEmpty())
+	})
+
+	It("Should report nil pointers", func() {
+		b.Parent = &diffStruct{Name: "p"}
+		changes, err := Diff(a, b)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changes).To(HaveLen(1))
+		Expect(changes[0].Path).To(Equal("Parent"))
+		Expect(changes[0].Old).To(BeNil())
+		Expect(changes[0].New).To(Equal(b.Parent))
+	})
+
+	It("Should stop at cycles", func() {
+		a.Parent = a
+		b.Parent = b
+		Expect(Diff(a, b)).To(BeEmpty())
+
+		b.Name = "b"
+		Expect(Diff(a, b)).To(HaveLen(1))
+	})
+
+	It("Should ignore fields", func() {
+		b.Name = "b"
+		b.Age = 2
+		b.version = 2
+		b.Lines[0].Title = "x"
+
+		changes, err := DiffWith(a, b, &DiffOptions{
+			IgnoreFields:     []string{"Name", "Lines[0].Title"},
+			IgnoreUnexported: true,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changes).To(Equal([]*Change{
+			{Path: "Age", Kind: ChangeModified, Old: 1, New: 2},
+		}))
+	})
+
+	It("Should compare slices as sets", func() {
+		b.Tags = []string{"z", "y"}
+		changes, err := DiffWith(a, b, &DiffOptions{SliceAsSet: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changes).To(Equal([]*Change{
+			{Path: "Tags[0]", Kind: ChangeRemoved, Old: "x"},
+			{Path: "Tags[0]", Kind: ChangeAdded, New: "z"},
+		}))
+
+		b.Tags = []string{"y", "x"}
+		Expect(DiffWith(a, b, &DiffOptions{SliceAsSet: true})).To(BeEmpty())
+	})
+
+	It("Should match slice items by key field", func() {
+		b.Lines = []*diffLine{{3, "three"}, {2, "zwei"}}
+		changes, err := DiffWith(a, b, &DiffOptions{
+			KeyFields: map[reflect.Type]string{reflect.TypeOf(diffLine{}): "ID"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changes).To(Equal([]*Change{
+			{Path: "Lines[ID=1]", Kind: ChangeRemoved, Old: a.Lines[0]},
+			{Path: "Lines[ID=2].Title", Kind: ChangeModified, Old: "two", New: "zwei"},
+			{Path: "Lines[ID=3]", Kind: ChangeAdded, New: b.Lines[0]},
+		}))
+	})
+
+	It("Should reject invalid key fields", func() {
+		_, err := DiffWith(a, b, &DiffOptions{
+			KeyFields: map[reflect.Type]string{reflect.TypeOf(diffLine{}): "Nope"},
+		})
+		Expect(errors.Is(err, ErrInvalidField)).To(BeTrue())
+	})
+})
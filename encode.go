@@ -0,0 +1,175 @@
+package reflector
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// visit identifies a pointer, map or slice that is being encoded.
+// The type is part of the key, since a struct and its first field share
+// the same address.
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// encoder converts values to a generic tree of map[string]interface{},
+// []interface{} and scalar values.
+type encoder struct {
+	opts *MapOptions
+	// seen holds the references on the current path, to detect cycles.
+	seen map[visit]bool
+}
+
+func newEncoder(opts *MapOptions) *encoder {
+	return &encoder{
+		opts: opts,
+		seen: make(map[visit]bool),
+	}
+}
+
+// enter marks a reference as being encoded.
+// It returns false if the reference is already on the current path,
+// which means the value references itself.
+func (e *encoder) enter(v reflect.Value) bool {
+	key := visit{v.Pointer(), v.Type()}
+	if e.seen[key] {
+		return false
+	}
+	e.seen[key] = true
+	return true
+}
+
+func (e *encoder) leave(v reflect.Value) {
+	delete(e.seen, visit{v.Pointer(), v.Type()})
+}
+
+// encode converts v to a generic value.
+// Structs are converted to maps, up to opts.MaxDepth, and slices, arrays
+// and maps are converted recursively. Pointers and interfaces are
+// de-referenced. []byte values and structs implementing
+// encoding.TextMarshaler or json.Marshaler are kept as they are, and so are
+// time.Time values, unless opts.TimeFormat is set.
+// References to values that are already being encoded are replaced by nil.
+func (e *encoder) encode(v reflect.Value, depth int) interface{} {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return e.encode(v.Elem(), depth)
+
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		if isStructValue(Reflect(v)) && e.opts.depthExceeded(depth) {
+			return v.Interface()
+		}
+		if !e.enter(v) {
+			return nil
+		}
+		defer e.leave(v)
+		return e.encode(v.Elem(), depth)
+
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return e.encodeTime(v)
+		}
+		if isMarshaler(v.Type()) && v.CanInterface() {
+			// Types like big.Int know how to serialize themselves.
+			return marshalerValue(v)
+		}
+		if e.opts.depthExceeded(depth) {
+			return v.Interface()
+		}
+		s, _ := newStructReflector(Reflect(v))
+		return s.toMap(e, depth+1)
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		if v.Len() > 0 {
+			if !e.enter(v) {
+				return nil
+			}
+			defer e.leave(v)
+		}
+		return e.encodeItems(v, depth)
+
+	case reflect.Array:
+		return e.encodeItems(v, depth)
+
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		if !e.enter(v) {
+			return nil
+		}
+		defer e.leave(v)
+
+		data := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			data[mapKeyString(iter.Key())] = e.encode(iter.Value(), depth)
+		}
+		return data
+	}
+
+	if !v.CanInterface() {
+		return nil
+	}
+	return v.Interface()
+}
+
+func (e *encoder) encodeItems(v reflect.Value, depth int) []interface{} {
+	items := make([]interface{}, v.Len(), v.Len())
+	for i := 0; i < v.Len(); i++ {
+		items[i] = e.encode(v.Index(i), depth)
+	}
+	return items
+}
+
+func (e *encoder) encodeTime(v reflect.Value) interface{} {
+	if e.opts.TimeFormat != "" {
+		if str, err := Reflect(v).ConvertToTypeWith(stringType, &e.opts.ConvertOptions); err == nil {
+			return str
+		}
+	}
+	return v.Interface()
+}
+
+// mapKeyString converts a map key to a string key of the generic tree.
+func mapKeyString(key reflect.Value) string {
+	if key.Kind() == reflect.Interface && !key.IsNil() {
+		key = key.Elem()
+	}
+	if key.Kind() == reflect.String {
+		return key.String()
+	}
+	if text, ok, err := marshalText(Reflect(key)); ok && err == nil {
+		return text
+	}
+	return fmt.Sprint(key.Interface())
+}
+
+// marshalerValue returns v, or a pointer to a copy of v if the marshal
+// methods of its type have a pointer receiver, like those of big.Int.
+// The copy keeps the result from pointing into the source value.
+func marshalerValue(v reflect.Value) interface{} {
+	typ := v.Type()
+	if typ.Implements(textMarshalerType) || typ.Implements(jsonMarshalerType) {
+		return v.Interface()
+	}
+	ptr := reflect.New(typ)
+	ptr.Elem().Set(v)
+	return ptr.Interface()
+}
@@ -0,0 +1,105 @@
+package reflector_test
+
+import (
+	"time"
+
+	. "github.com/theduke/go-reflector"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type encodeItem struct {
+	Name string
+}
+
+type encodeNode struct {
+	Name     string
+	Next     *encodeNode
+	Children []*encodeNode
+}
+
+type encodeStruct struct {
+	Items    []encodeItem
+	ItemPtrs []*encodeItem
+	ByName   map[string]encodeItem
+	ByID     map[int]*encodeItem
+	Fixed    [2]int
+	Matrix   [][]encodeItem
+	Count    *int
+	Bytes    []byte
+	Times    []time.Time
+	Any      interface{}
+}
+
+var _ = Describe("ToMap encoding", func() {
+	It("Should convert nested slices, maps and pointers", func() {
+		count := 3
+		s := encodeStruct{
+			Items:    []encodeItem{{"a"}},
+			ItemPtrs: []*encodeItem{{"p"}, nil},
+			ByName:   map[string]encodeItem{"x": {"x"}},
+			ByID:     map[int]*encodeItem{7: {"seven"}},
+			Fixed:    [2]int{1, 2},
+			Matrix:   [][]encodeItem{{{"m"}}},
+			Count:    &count,
+			Bytes:    []byte("b"),
+			Any:      []encodeItem{{"any"}},
+		}
+
+		data := R(s).MustStruct().ToMap(true, false)
+		Expect(data).To(Equal(map[string]interface{}{
+			"Items":    []interface{}{map[string]interface{}{"Name": "a"}},
+			"ItemPtrs": []interface{}{map[string]interface{}{"Name": "p"}, nil},
+			"ByName":   map[string]interface{}{"x": map[string]interface{}{"Name": "x"}},
+			"ByID":     map[string]interface{}{"7": map[string]interface{}{"Name": "seven"}},
+			"Fixed":    []interface{}{1, 2},
+			"Matrix":   []interface{}{[]interface{}{map[string]interface{}{"Name": "m"}}},
+			"Count":    3,
+			"Bytes":    []byte("b"),
+			"Any":      []interface{}{map[string]interface{}{"Name": "any"}},
+		}))
+	})
+
+	It("Should format times in slices", func() {
+		t := time.Date(2015, 11, 4, 0, 0, 0, 0, time.UTC)
+		s := encodeStruct{Times: []time.Time{t}}
+
+		data := R(s).MustStruct().ToMapWith(&MapOptions{Zero: ZeroOmit})
+		Expect(data["Times"]).To(Equal([]interface{}{t}))
+
+		data = R(s).MustStruct().ToMapWith(&MapOptions{
+			Zero:           ZeroOmit,
+			ConvertOptions: ConvertOptions{TimeFormat: "2006-01-02"},
+		})
+		Expect(data["Times"]).To(Equal([]interface{}{"2015-11-04"}))
+	})
+
+	It("Should replace cyclic references with nil", func() {
+		a := &encodeNode{Name: "a"}
+		b := &encodeNode{Name: "b", Next: a}
+		a.Next = b
+		a.Children = []*encodeNode{a, b}
+
+		data := R(a).MustStruct().ToMap(true, false)
+		Expect(data).To(Equal(map[string]interface{}{
+			"Name": "a",
+			"Next": map[string]interface{}{"Name": "b", "Next": nil},
+			"Children": []interface{}{
+				nil,
+				map[string]interface{}{"Name": "b", "Next": nil},
+			},
+		}))
+	})
+
+	It("Should keep shared references that are not cyclic", func() {
+		shared := &encodeItem{"s"}
+		s := encodeStruct{ItemPtrs: []*encodeItem{shared, shared}}
+
+		data := R(s).MustStruct().ToMapWith(&MapOptions{Zero: ZeroOmit})
+		Expect(data["ItemPtrs"]).To(Equal([]interface{}{
+			map[string]interface{}{"Name": "s"},
+			map[string]interface{}{"Name": "s"},
+		}))
+	})
+})
@@ -0,0 +1,226 @@
+package reflector
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Error is the error type returned by this package.
+//
+// Use errors.Is with one of the Err* sentinel values to check the error code,
+// or errors.As to access the path, the involved types and the wrapped cause.
+type Error struct {
+	// Code is one of the ERR_* constants.
+	Code string
+
+	// Path is the field or index path where the error happened,
+	// for example "Address.Lines[2].Street". Empty for top level values.
+	Path string
+
+	// Source is the type of the value that was converted or assigned, if known.
+	Source reflect.Type
+
+	// Target is the type that was converted or assigned to, if known.
+	Target reflect.Type
+
+	// Message holds optional additional information.
+	Message string
+
+	// Err is the underlying error, for example from strconv or time.
+	Err error
+}
+
+func newError(code string) *Error {
+	return &Error{Code: code}
+}
+
+func (e *Error) Error() string {
+	msg := e.Code
+	if e.Source != nil && e.Target != nil {
+		msg += fmt.Sprintf(" (%v => %v)", e.Source, e.Target)
+	} else if e.Target != nil {
+		msg += fmt.Sprintf(" (%v)", e.Target)
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.Err != nil {
+		if msg != "" {
+			msg += ": "
+		}
+		msg += e.Err.Error()
+	}
+	if e.Path != "" {
+		msg = e.Path + ": " + msg
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is an *Error with the same code.
+// This allows checks like errors.Is(err, reflector.ErrTypeMismatch).
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return t.Code == e.Code
+}
+
+// Sentinel errors for each error code, to be used with errors.Is.
+var (
+	ErrUnknownField         = newError(ERR_UNKNOWN_FIELD)
+	ErrUnknownKey           = newError(ERR_UNKNOWN_KEY)
+	ErrMissingField         = newError(ERR_MISSING_FIELD)
+	ErrInvalidField         = newError(ERR_INVALID_FIELD)
+	ErrUninterfaceableField = newError(ERR_UNINTERFACEABLE_FIELD)
+
+	ErrInvalidTime        = newError(ERR_INVALID_TIME)
+	ErrUnconvertableTypes = newError(ERR_UNCONVERTABLE_TYPES)
+
+	ErrPointerOrStructExpected = newError(ERR_POINTER_OR_STRUCT_EXPECTED)
+	ErrInvalidValue            = newError(ERR_INVALID_VALUE)
+	ErrStructExpected          = newError(ERR_STRUCT_EXPECTED)
+	ErrNilPointer              = newError(ERR_NIL_POINTER)
+	ErrNilSlicePointer         = newError(ERR_NIL_SLICE_POINTER)
+	ErrNotAStruct              = newError(ERR_NOT_A_STRUCT)
+	ErrNotASlice               = newError(ERR_NOT_A_SLICE)
+	ErrNotAMap                 = newError(ERR_NOT_A_MAP)
+	ErrNilMap                  = newError(ERR_NIL_MAP)
+	ErrInvalidTypesOrNilMap    = newError(ERR_INVALID_TYPES_OR_NIL_MAP)
+	ErrUnsettableValue         = newError(ERR_UNSETTABLE_VALUE)
+	ErrTypeMismatch            = newError(ERR_TYPE_MISMATCH)
+	ErrUncomparableValues      = newError(ERR_UNCOMPARABLE_VALUES)
+	ErrInvalidComparison       = newError(ERR_INVALID_COMPARISON)
+	ErrUnknownOperator         = newError(ERR_UNKNOWN_OPERATOR)
+	ErrCantAppendNotAPointer   = newError(ERR_CANT_APPEND_NOT_A_POINTER)
+	ErrIndexOutOfBounds        = newError(ERR_INDEX_OUT_OF_BOUNDS)
+	ErrInvalidPath             = newError(ERR_INVALID_PATH)
+	ErrNumericOverflow         = newError(ERR_NUMERIC_OVERFLOW)
+	ErrLossyConversion         = newError(ERR_LOSSY_CONVERSION)
+	ErrPatchTestFailed         = newError(ERR_PATCH_TEST_FAILED)
+	ErrValidationFailed        = newError(ERR_VALIDATION_FAILED)
+	ErrUnknownValidator        = newError(ERR_UNKNOWN_VALIDATOR)
+
+	// ErrSkipConverter may be returned by a ConverterFunc to fall back to
+	// the builtin conversion rules.
+	ErrSkipConverter = newError(ERR_SKIP_CONVERTER)
+
+	// ErrSkipNode may be returned by a WalkFunc to skip the children of
+	// the current node.
+	ErrSkipNode = newError(ERR_SKIP_NODE)
+
+	// ErrStopWalk may be returned by a WalkFunc to stop walking.
+	ErrStopWalk = newError(ERR_STOP_WALK)
+)
+
+// joinPath joins two path fragments.
+// Index fragments like "[2]" are appended without a separating dot.
+func joinPath(prefix, path string) string {
+	if prefix == "" {
+		return path
+	}
+	if path == "" {
+		return prefix
+	}
+	if strings.HasPrefix(path, "[") {
+		return prefix + path
+	}
+	return prefix + "." + path
+}
+
+// indexPath returns the path fragment for a slice or array index.
+func indexPath(index int) string {
+	return fmt.Sprintf("[%v]", index)
+}
+
+// MultiError combines multiple errors, for example all problems found by
+// FromMapWith in strict mode.
+// errors.Is and errors.As check each contained error.
+type MultiError struct {
+	Errors []*Error
+}
+
+func (e *MultiError) Error() string {
+	if len(e.Errors) == 1 {
+		return e.Errors[0].Error()
+	}
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%v errors: %v", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the contained errors.
+func (e *MultiError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// errorList collects errors if collect is true.
+type errorList struct {
+	collect bool
+	errors  []*Error
+}
+
+// add records err.
+// If errors are not collected, err is returned so the caller can abort.
+func (l *errorList) add(err error) error {
+	if err == nil {
+		return nil
+	}
+	if !l.collect {
+		return err
+	}
+
+	switch e := err.(type) {
+	case *MultiError:
+		l.errors = append(l.errors, e.Errors...)
+	case *Error:
+		l.errors = append(l.errors, e)
+	default:
+		l.errors = append(l.errors, &Error{Err: err})
+	}
+	return nil
+}
+
+// err returns the collected errors as a *MultiError, or nil.
+func (l *errorList) err() error {
+	if len(l.errors) == 0 {
+		return nil
+	}
+	return &MultiError{Errors: l.errors}
+}
+
+// prefixPath prepends prefix to the path of err.
+// Errors that are not of type *Error are wrapped.
+func prefixPath(err error, prefix string) error {
+	if err == nil {
+		return nil
+	}
+	if multi, ok := err.(*MultiError); ok {
+		errs := make([]*Error, len(multi.Errors))
+		for i, e := range multi.Errors {
+			errs[i] = prefixPath(e, prefix).(*Error)
+		}
+		return &MultiError{Errors: errs}
+	}
+	if e, ok := err.(*Error); ok {
+		newErr := *e
+		newErr.Path = joinPath(prefix, e.Path)
+		return &newErr
+	}
+	return &Error{
+		Path: prefix,
+		Err:  err,
+	}
+}
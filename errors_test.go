@@ -0,0 +1,78 @@
+package reflector_test
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"time"
+
+	. "github.com/theduke/go-reflector"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Error", func() {
+	It("Should match sentinels with errors.Is", func() {
+		_, err := Reflect(22).Struct()
+		Expect(errors.Is(err, ErrNotAStruct)).To(BeTrue())
+		Expect(errors.Is(err, ErrNotASlice)).To(BeFalse())
+	})
+
+	It("Should expose source and target types", func() {
+		s := &testStruct{}
+		err := Reflect(s).MustStruct().SetFieldValue("Int", "x")
+
+		var rErr *Error
+		Expect(errors.As(err, &rErr)).To(BeTrue())
+		Expect(rErr.Code).To(Equal(ERR_TYPE_MISMATCH))
+		Expect(rErr.Path).To(Equal("Int"))
+		Expect(rErr.Source).To(Equal(reflect.TypeOf("")))
+		Expect(rErr.Target).To(Equal(reflect.TypeOf(0)))
+	})
+
+	It("Should wrap the underlying cause", func() {
+		_, err := Reflect("abc").ConvertTo(0)
+		Expect(errors.Is(err, ErrUnconvertableTypes)).To(BeTrue())
+
+		var numErr *strconv.NumError
+		Expect(errors.As(err, &numErr)).To(BeTrue())
+	})
+
+	It("Should wrap time parse errors", func() {
+		_, err := Reflect("333").ConvertTo(time.Time{})
+		Expect(errors.Is(err, ErrInvalidTime)).To(BeTrue())
+
+		var timeErr *time.ParseError
+		Expect(errors.As(err, &timeErr)).To(BeTrue())
+	})
+
+	It("Should report the full path of nested FromMap errors", func() {
+		d := map[string]interface{}{
+			"EmbeddedPtr": map[string]interface{}{
+				"Int": "not a number",
+			},
+		}
+
+		s := &nestedStruct{}
+		err := Reflect(s).MustStruct().FromMap(d, true)
+		Expect(err).To(HaveOccurred())
+
+		var rErr *Error
+		Expect(errors.As(err, &rErr)).To(BeTrue())
+		Expect(rErr.Path).To(Equal("EmbeddedPtr.Int"))
+		Expect(err.Error()).To(HavePrefix("EmbeddedPtr.Int: " + ERR_UNCONVERTABLE_TYPES))
+	})
+
+	It("Should report slice index paths", func() {
+		_, err := Reflect([]interface{}{1, "x"}).MustSlice().ConvertTo(0)
+
+		var rErr *Error
+		Expect(errors.As(err, &rErr)).To(BeTrue())
+		Expect(rErr.Path).To(Equal("[1]"))
+	})
+
+	It("Should format plain codes without decoration", func() {
+		Expect(ErrUnknownField.Error()).To(Equal(ERR_UNKNOWN_FIELD))
+	})
+})
@@ -0,0 +1,240 @@
+package reflector
+
+import (
+	"errors"
+	"reflect"
+)
+
+type conditionKind int
+
+const (
+	conditionWhere conditionKind = iota
+	conditionAnd
+	conditionOr
+	conditionNot
+)
+
+// Condition is a filter condition for slice items, created with Where and
+// combined with And, Or and Not, or WhereAll, WhereAny and WhereNot.
+//
+//	Where("Age", ">=", 18).And(Where("Name", "like", "jo").Or(Where("Admin", "=", true)))
+type Condition struct {
+	kind       conditionKind
+	field      string
+	operator   string
+	value      interface{}
+	opts       *CompareOptions
+	conditions []*Condition
+}
+
+// Where returns a condition that compares the value at field with value,
+// using CompareTo and the given operator.
+// field may be a path like "Address.City" or `Tags["env"]`, see
+// Reflector.Get, or empty to compare the item itself.
+// Missing map keys and nil pointers along the path are compared as nil.
+func Where(field, operator string, value interface{}) *Condition {
+	return WhereWith(field, operator, value, nil)
+}
+
+// WhereWith returns a condition like Where, which compares values with
+// CompareToWith and the given options.
+func WhereWith(field, operator string, value interface{}, opts *CompareOptions) *Condition {
+	return &Condition{
+		kind:     conditionWhere,
+		field:    field,
+		operator: operator,
+		value:    value,
+		opts:     opts,
+	}
+}
+
+// WhereAll returns a condition that matches if all conditions match.
+// An empty WhereAll matches everything.
+func WhereAll(conditions ...*Condition) *Condition {
+	return &Condition{kind: conditionAnd, conditions: conditions}
+}
+
+// WhereAny returns a condition that matches if any condition matches.
+// An empty WhereAny matches nothing.
+func WhereAny(conditions ...*Condition) *Condition {
+	return &Condition{kind: conditionOr, conditions: conditions}
+}
+
+// WhereNot returns a condition that matches if condition does not match.
+// Comparisons with nil values don't match either way, see Match.
+func WhereNot(condition *Condition) *Condition {
+	return &Condition{kind: conditionNot, conditions: []*Condition{condition}}
+}
+
+// And returns a condition that matches if c and all conditions match.
+func (c *Condition) And(conditions ...*Condition) *Condition {
+	return WhereAll(append([]*Condition{c}, conditions...)...)
+}
+
+// Or returns a condition that matches if c or any of the conditions match.
+func (c *Condition) Or(conditions ...*Condition) *Condition {
+	return WhereAny(append([]*Condition{c}, conditions...)...)
+}
+
+// Not returns a condition that matches if c does not match.
+func (c *Condition) Not() *Condition {
+	return WhereNot(c)
+}
+
+// Match returns true if item matches the condition.
+// item may be a struct, a struct pointer, a map or any value for conditions
+// on the item itself.
+//
+// Like NULL in SQL, comparisons with missing values, nil pointers and nil
+// interfaces are neither true nor false, so negating them with Not does not
+// match either.
+func (c *Condition) Match(item interface{}) (bool, error) {
+	result, err := c.match(reflectValue(item))
+	return result == matchTrue, err
+}
+
+// matchResult is the result of a condition, which is unknown for
+// comparisons with nil values.
+type matchResult int
+
+const (
+	matchFalse matchResult = iota
+	matchTrue
+	matchUnknown
+)
+
+func (c *Condition) match(item reflect.Value) (matchResult, error) {
+	if c == nil {
+		return matchFalse, &Error{Code: ERR_INVALID_VALUE, Message: "nil condition"}
+	}
+
+	switch c.kind {
+	case conditionAnd, conditionOr:
+		// A false condition decides And, and a true condition decides Or.
+		decisive, result := matchFalse, matchTrue
+		if c.kind == conditionOr {
+			decisive, result = matchTrue, matchFalse
+		}
+		for _, condition := range c.conditions {
+			ok, err := condition.match(item)
+			if err != nil {
+				return matchFalse, err
+			}
+			if ok == decisive {
+				return ok, nil
+			}
+			if ok == matchUnknown {
+				result = matchUnknown
+			}
+		}
+		return result, nil
+
+	case conditionNot:
+		ok, err := c.conditions[0].match(item)
+		switch ok {
+		case matchTrue:
+			return matchFalse, err
+		case matchFalse:
+			return matchTrue, err
+		}
+		return ok, err
+	}
+
+	value, err := conditionValue(item, c.field)
+	if err != nil {
+		return matchFalse, err
+	}
+	ok, err := Reflect(value).CompareToWith(c.value, c.operator, c.opts)
+	if err != nil {
+		return matchFalse, prefixPath(err, c.field)
+	}
+	if ok {
+		return matchTrue, nil
+	}
+	if c.comparesNull(value) {
+		return matchUnknown, nil
+	}
+	return matchFalse, nil
+}
+
+// comparesNull returns true if the condition compares a missing value, a nil
+// pointer or a nil interface, unless CompareOptions.CoerceNil is set.
+func (c *Condition) comparesNull(value reflect.Value) bool {
+	if c.opts != nil && c.opts.CoerceNil {
+		return false
+	}
+	switch c.operator {
+	case "is null", "not null":
+		return false
+	case "in", "not in", "between", "contains", "contains any":
+		return isNull(value)
+	}
+	return isNull(value) || isNull(reflectValue(c.value))
+}
+
+// isNull returns true for invalid values, nil pointers and nil interfaces.
+func isNull(v reflect.Value) bool {
+	for v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// conditionValue returns the value at field, or an invalid value if a map
+// key is missing or the path contains nil values.
+func conditionValue(item reflect.Value, field string) (reflect.Value, error) {
+	value := item
+	if field != "" {
+		segments, err := parsePath(field)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		value, err = getPath(item, segments)
+		switch {
+		case errors.Is(err, ErrUnknownKey), errors.Is(err, ErrNilPointer),
+			errors.Is(err, ErrInvalidValue), errors.Is(err, ErrIndexOutOfBounds):
+			return reflect.Value{}, nil
+		case err != nil:
+			return reflect.Value{}, err
+		}
+	}
+
+	for value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+	return value, nil
+}
+
+// FilterWhere returns a new slice with the items whose field matches the
+// value, like FilterBy with Where(field, operator, value).
+// See Where for details.
+func (s *SliceReflector) FilterWhere(field, operator string, value interface{}) (*SliceReflector, error) {
+	return s.Filter(Where(field, operator, value))
+}
+
+// Filter returns a new slice with the items that match the condition.
+// Errors are prefixed with the index of the item.
+func (s *SliceReflector) Filter(condition *Condition) (*SliceReflector, error) {
+	items := s.sliceValue.Value()
+	filtered := reflect.MakeSlice(reflect.SliceOf(s.Type()), 0, 0)
+	for i := 0; i < items.Len(); i++ {
+		ok, err := condition.match(items.Index(i))
+		if err != nil {
+			return nil, prefixPath(err, indexPath(i))
+		}
+		if ok == matchTrue {
+			filtered = reflect.Append(filtered, items.Index(i))
+		}
+	}
+
+	// Use a pointer, so the result can be appended to.
+	ptr := reflect.New(filtered.Type())
+	ptr.Elem().Set(filtered)
+	return newSliceReflector(Reflect(ptr))
+}
@@ -0,0 +1,190 @@
+package reflector_test
+
+import (
+	"errors"
+
+	. "github.com/theduke/go-reflector"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type filterAddress struct {
+	City string
+}
+
+type filterUser struct {
+	Name    string
+	Age     int
+	Admin   bool
+	Address *filterAddress
+	Tags    []string
+}
+
+type filterMember struct {
+	*filterAddress
+	Name string
+}
+
+var _ = Describe("Filter", func() {
+	var users []filterUser
+
+	BeforeEach(func() {
+		users = []filterUser{
+			{Name: "John", Age: 30, Address: &filterAddress{City: "Vienna"}, Tags: []string{"go"}},
+			{Name: "Joanna", Age: 17, Address: &filterAddress{City: "Berlin"}},
+			{Name: "Max", Age: 45, Admin: true, Tags: []string{"go", "rust"}},
+			{Name: "Anna", Age: 22, Address: &filterAddress{City: "Vienna"}},
+		}
+	})
+
+	names := func(s *SliceReflector) []string {
+		result := make([]string, 0)
+		for _, item := range s.Items() {
+			if item.IsPtr() {
+				item = item.Elem()
+			}
+			name, err := item.Get("Name")
+			Expect(err).ToNot(HaveOccurred())
+			result = append(result, name.Interface().(string))
+		}
+		return result
+	}
+
+	It("Should filter with .FilterWhere()", func() {
+		filtered, err := R(users).MustSlice().FilterWhere("Age", ">=", 18)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(names(filtered)).To(Equal([]string{"John", "Max", "Anna"}))
+		Expect(filtered.Interface()).To(BeAssignableToTypeOf([]filterUser{}))
+
+		filtered, err = R(users).MustSlice().FilterWhere("Name", "in", []string{"Max", "Anna"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(names(filtered)).To(Equal([]string{"Max", "Anna"}))
+	})
+
+	It("Should combine conditions", func() {
+		s := R(users).MustSlice()
+
+		filtered, err := s.Filter(Where("Age", ">=", 18).And(Where("Name", "like", "Jo")))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(names(filtered)).To(Equal([]string{"John"}))
+
+		filtered, err = s.Filter(WhereAny(Where("Admin", "=", true), Where("Age", "<", 18)))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(names(filtered)).To(Equal([]string{"Joanna", "Max"}))
+
+		filtered, err = s.Filter(Where("Tags", "contains", "go").Not().Or(Where("Name", "prefix", "M")))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(names(filtered)).To(Equal([]string{"Joanna", "Max", "Anna"}))
+
+		// Max has no address, so the condition is unknown for him.
+		filtered, err = s.Filter(WhereNot(WhereAll(Where("Age", ">", 20), Where("Address.City", "=", "Vienna"))))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(names(filtered)).To(Equal([]string{"Joanna"}))
+
+		filtered, err = s.Filter(WhereAll())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filtered.Len()).To(Equal(4))
+
+		filtered, err = s.Filter(WhereAny())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filtered.Len()).To(Equal(0))
+	})
+
+	It("Should filter by nested paths", func() {
+		ptrs := []*filterUser{&users[0], &users[1], &users[2], nil}
+
+		filtered, err := R(ptrs).MustSlice().FilterWhere("Address.City", "=", "Vienna")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filtered.Interface()).To(Equal([]*filterUser{&users[0]}))
+
+		filtered, err = R(ptrs).MustSlice().FilterWhere("Address", "is null", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filtered.Interface()).To(Equal([]*filterUser{&users[2], nil}))
+
+		filtered, err = R(users).MustSlice().Filter(WhereWith("Address.City", "=", "VIENNA", &CompareOptions{IgnoreCase: true}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(names(filtered)).To(Equal([]string{"John", "Anna"}))
+	})
+
+	It("Should filter by fields of nil embedded pointers", func() {
+		members := []filterMember{{&filterAddress{City: "Vienna"}, "a"}, {nil, "b"}}
+
+		filtered, err := R(members).MustSlice().FilterWhere("City", "=", "Vienna")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(names(filtered)).To(Equal([]string{"a"}))
+
+		filtered, err = R(members).MustSlice().FilterWhere("City", "is null", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(names(filtered)).To(Equal([]string{"b"}))
+	})
+
+	It("Should not negate comparisons with nil values", func() {
+		type item struct{ Age *int }
+		age := 30
+		items := []item{{nil}, {&age}}
+
+		filtered, err := R(items).MustSlice().Filter(Where("Age", ">", 18).Not())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filtered.Len()).To(Equal(0))
+
+		filtered, err = R(items).MustSlice().Filter(Where("Age", ">", 40).Not())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filtered.Interface()).To(Equal(items[1:]))
+
+		filtered, err = R(items).MustSlice().Filter(WhereNot(Where("Age", ">", 18).Or(Where("Age", "is null", nil))))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filtered.Len()).To(Equal(0))
+
+		filtered, err = R(items).MustSlice().Filter(WhereWith("Age", "<", 18, &CompareOptions{CoerceNil: true}).Not())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filtered.Interface()).To(Equal(items[1:]))
+	})
+
+	It("Should reject nil conditions", func() {
+		_, err := R(users).MustSlice().Filter(WhereNot(nil))
+		Expect(errors.Is(err, ErrInvalidValue)).To(BeTrue())
+
+		_, err = R(users).MustSlice().Filter(WhereAll(Where("Age", ">", 1), nil))
+		Expect(errors.Is(err, ErrInvalidValue)).To(BeTrue())
+
+		_, err = R(users).MustSlice().Filter(nil)
+		Expect(errors.Is(err, ErrInvalidValue)).To(BeTrue())
+	})
+
+	It("Should filter maps", func() {
+		rows := []map[string]interface{}{
+			{"name": "a", "meta": map[string]interface{}{"env": "prod"}},
+			{"name": "b", "meta": map[string]interface{}{"env": nil}},
+			{"name": "c"},
+		}
+
+		filtered, err := R(rows).MustSlice().FilterWhere("meta.env", "=", "prod")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filtered.Interface()).To(Equal(rows[:1]))
+
+		filtered, err = R(rows).MustSlice().FilterWhere(`meta["env"]`, "is null", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filtered.Interface()).To(Equal(rows[1:]))
+	})
+
+	It("Should filter items by value", func() {
+		filtered, err := R([]int{5, 12, 18, 3}).MustSlice().Filter(Where("", "between", []int{4, 15}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filtered.Interface()).To(Equal([]int{5, 12}))
+
+		ok, err := Where("Name", "=", "Max").Match(&users[2])
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ok).To(BeTrue())
+	})
+
+	It("Should report errors with the item index", func() {
+		_, err := R(users).MustSlice().FilterWhere("Nope", "=", 1)
+		Expect(errors.Is(err, ErrUnknownField)).To(BeTrue())
+		Expect(err.(*Error).Path).To(Equal("[0].Nope"))
+
+		_, err = R(users).MustSlice().FilterWhere("Age", "~", 1)
+		Expect(errors.Is(err, ErrUnknownOperator)).To(BeTrue())
+		Expect(err.(*Error).Path).To(Equal("[0].Age"))
+	})
+})
@@ -0,0 +1,334 @@
+package reflector
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+type MapReflector struct {
+	value    *Reflector
+	mapValue *Reflector
+}
+
+// MapEntry is a single key/value pair of a map.
+type MapEntry struct {
+	Key   *Reflector
+	Value *Reflector
+}
+
+// newMapReflector builds a new MapReflector.
+// You may pass in a map or a pointer to a map.
+// Nil maps behind a pointer are initialized automatically.
+func newMapReflector(v *Reflector) (*MapReflector, error) {
+	if !v.IsValid() {
+		return nil, newError(ERR_INVALID_VALUE)
+	}
+
+	// Dereference interfaces.
+	if v.IsInterface() {
+		if v.IsNil() {
+			return nil, newError(ERR_INVALID_VALUE)
+		}
+		v = v.Elem()
+	}
+
+	if v.IsMap() {
+		return &MapReflector{
+			value:    v,
+			mapValue: v,
+		}, nil
+	}
+
+	if v.IsPtr() && v.Type().Elem().Kind() == reflect.Map {
+		if v.IsNil() {
+			newMap := New(v.Type().Elem())
+			if err := v.Set(newMap); err != nil {
+				return nil, newError(ERR_NIL_POINTER)
+			}
+		}
+
+		if v.Elem().IsNil() {
+			m := reflect.MakeMap(v.Type().Elem())
+			if err := v.Elem().Set(Reflect(m)); err != nil {
+				return nil, err
+			}
+		}
+
+		return &MapReflector{
+			value:    v,
+			mapValue: v.Elem(),
+		}, nil
+	}
+
+	return nil, newError(ERR_NOT_A_MAP)
+}
+
+func (m *MapReflector) String() string {
+	return m.value.String()
+}
+
+func (m *MapReflector) Interface() interface{} {
+	return m.mapValue.Interface()
+}
+
+func (m *MapReflector) Value() *Reflector {
+	return m.value
+}
+
+// Type returns the type of the map itself.
+func (m *MapReflector) Type() reflect.Type {
+	return m.mapValue.Type()
+}
+
+// KeyType returns the type of the map keys.
+func (m *MapReflector) KeyType() reflect.Type {
+	return m.Type().Key()
+}
+
+// ValueType returns the type of the map values.
+func (m *MapReflector) ValueType() reflect.Type {
+	return m.Type().Elem()
+}
+
+func (m *MapReflector) Len() int {
+	return m.mapValue.Len()
+}
+
+func (m *MapReflector) IsNil() bool {
+	return m.mapValue.IsNil()
+}
+
+// New creates a new, empty map of the same type.
+func (m *MapReflector) New() *MapReflector {
+	ptr := New(m.Type())
+	refl, err := newMapReflector(ptr)
+
+	// This should never happen.
+	// Panic is there just to make sure.
+	if err != nil {
+		panic(err)
+	}
+	return refl
+}
+
+// mapKey returns a reflect.Value that can be used as a key for the map.
+// If convert is true, the key is converted to the key type if neccessary.
+func (m *MapReflector) mapKey(key *Reflector, convert bool, opts *ConvertOptions) (reflect.Value, error) {
+	if key == nil || !key.IsValid() {
+		return reflect.Value{}, newError(ERR_INVALID_VALUE)
+	}
+
+	keyType := m.KeyType()
+	if key.Type().AssignableTo(keyType) {
+		return key.Value(), nil
+	}
+
+	if !convert {
+		return reflect.Value{}, &Error{
+			Code:   ERR_TYPE_MISMATCH,
+			Source: key.Type(),
+			Target: keyType,
+		}
+	}
+
+	converted, err := key.ConvertToTypeWith(keyType, opts)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return reflect.ValueOf(converted), nil
+}
+
+// Get returns a Reflector for the value stored under key, or nil if the key
+// does not exist.
+// Values stored in interface maps are de-referenced, nil values result in a
+// Reflector for which IsNil is true.
+func (m *MapReflector) Get(key interface{}) *Reflector {
+	k, err := m.mapKey(Reflect(key), false, nil)
+	if err != nil {
+		return nil
+	}
+
+	val := m.mapValue.Value().MapIndex(k)
+	if !val.IsValid() {
+		return nil
+	}
+
+	return mapItem(val)
+}
+
+// mapItem returns a Reflector for a map value, de-referencing interfaces.
+// nil interfaces result in an invalid Reflector, for which IsNil is true.
+func mapItem(val reflect.Value) *Reflector {
+	for val.Kind() == reflect.Interface {
+		val = val.Elem()
+	}
+	return Reflect(val)
+}
+
+// GetValue returns the value stored under key, or nil if the key does not exist.
+func (m *MapReflector) GetValue(key interface{}) interface{} {
+	if v := m.Get(key); v != nil {
+		return v.Interface()
+	}
+	return nil
+}
+
+func (m *MapReflector) Has(key interface{}) bool {
+	k, err := m.mapKey(Reflect(key), false, nil)
+	if err != nil {
+		return false
+	}
+	return m.mapValue.Value().MapIndex(k).IsValid()
+}
+
+// Set stores value under key.
+// If convert is true, both key and value are converted to the map
+// key and value types if neccessary.
+func (m *MapReflector) Set(key, value *Reflector, convert ...bool) error {
+	return m.SetWith(key, value, convertOptions(convert))
+}
+
+// SetWith stores value under key, converting key and value according to
+// the given options if neccessary.
+func (m *MapReflector) SetWith(key, value *Reflector, opts *ConvertOptions) error {
+	if m.IsNil() {
+		return newError(ERR_NIL_MAP)
+	}
+
+	doConvert := opts.converts()
+
+	k, err := m.mapKey(key, doConvert, opts)
+	if err != nil {
+		return err
+	}
+
+	valueType := m.ValueType()
+	var val reflect.Value
+
+	if value == nil || !value.IsValid() {
+		// Store the zero value, since setting an invalid value would
+		// delete the key.
+		val = reflect.Zero(valueType)
+	} else if value.Type().AssignableTo(valueType) {
+		val = value.Value()
+	} else if doConvert {
+		converted, err := value.ConvertToTypeWith(valueType, opts)
+		if err != nil {
+			return err
+		}
+		val = reflect.ValueOf(converted)
+	} else {
+		return &Error{
+			Code:   ERR_TYPE_MISMATCH,
+			Source: value.Type(),
+			Target: valueType,
+		}
+	}
+
+	m.mapValue.Value().SetMapIndex(k, val)
+	return nil
+}
+
+func (m *MapReflector) SetValue(key, value interface{}, convert ...bool) error {
+	return m.Set(Reflect(key), Reflect(value), convert...)
+}
+
+// Delete removes the key from the map.
+// Deleting a key that does not exist is not an error.
+func (m *MapReflector) Delete(key interface{}) error {
+	if m.IsNil() {
+		return nil
+	}
+	k, err := m.mapKey(Reflect(key), false, nil)
+	if err != nil {
+		return err
+	}
+	m.mapValue.Value().SetMapIndex(k, reflect.Value{})
+	return nil
+}
+
+// Keys returns all map keys in unspecified order.
+func (m *MapReflector) Keys() []*Reflector {
+	keys := m.mapValue.Value().MapKeys()
+	sl := make([]*Reflector, len(keys), len(keys))
+	for i, key := range keys {
+		sl[i] = Reflect(key)
+	}
+	return sl
+}
+
+// SortedKeys returns all map keys in ascending order.
+// Strings and numbers are sorted by value, all other key types by their
+// string representation.
+func (m *MapReflector) SortedKeys() []*Reflector {
+	keys := m.Keys()
+	sort.SliceStable(keys, func(i, j int) bool {
+		return lessMapKey(keys[i], keys[j])
+	})
+	return keys
+}
+
+// Values returns all map values in unspecified order.
+// Values stored in interface maps are de-referenced.
+func (m *MapReflector) Values() []*Reflector {
+	entries := m.Entries()
+	sl := make([]*Reflector, len(entries), len(entries))
+	for i, entry := range entries {
+		sl[i] = entry.Value
+	}
+	return sl
+}
+
+// Entries returns all key/value pairs in unspecified order.
+func (m *MapReflector) Entries() []*MapEntry {
+	return m.entries(m.Keys())
+}
+
+// SortedEntries returns all key/value pairs, sorted by key like SortedKeys.
+func (m *MapReflector) SortedEntries() []*MapEntry {
+	return m.entries(m.SortedKeys())
+}
+
+func (m *MapReflector) entries(keys []*Reflector) []*MapEntry {
+	sl := make([]*MapEntry, len(keys), len(keys))
+	for i, key := range keys {
+		sl[i] = &MapEntry{
+			Key:   key,
+			Value: mapItem(m.mapValue.Value().MapIndex(key.Value())),
+		}
+	}
+	return sl
+}
+
+// EachSorted calls f for every entry in sorted key order.
+// Iteration stops at the first error, which is returned.
+func (m *MapReflector) EachSorted(f func(key, value *Reflector) error) error {
+	for _, entry := range m.SortedEntries() {
+		if err := f(entry.Key, entry.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func lessMapKey(a, b *Reflector) bool {
+	if a.IsInterface() && !a.IsNil() {
+		a = a.Elem()
+	}
+	if b.IsInterface() && !b.IsNil() {
+		b = b.Elem()
+	}
+
+	if a.IsString() && b.IsString() {
+		return a.Value().String() < b.Value().String()
+	}
+	if a.IsNumeric() && b.IsNumeric() {
+		numA, errA := a.ConvertTo(float64(0))
+		numB, errB := b.ConvertTo(float64(0))
+		if errA == nil && errB == nil {
+			return numA.(float64) < numB.(float64)
+		}
+	}
+	return fmt.Sprintf("%v", a.Interface()) < fmt.Sprintf("%v", b.Interface())
+}
@@ -0,0 +1,152 @@
+package reflector_test
+
+import (
+	"reflect"
+
+	. "github.com/theduke/go-reflector"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Map", func() {
+	It("Should return error on Map() with non-map", func() {
+		_, err := Reflect(22).Map()
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Should create MapReflector from map", func() {
+		m, err := Reflect(map[string]int{"a": 1}).Map()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(m.Len()).To(Equal(1))
+	})
+
+	It("Should initialize nil map behind pointer", func() {
+		var m map[string]int
+		r, err := Reflect(&m).Map()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(m).ToNot(BeNil())
+		Expect(r.SetValue("a", 1)).ToNot(HaveOccurred())
+		Expect(m["a"]).To(Equal(1))
+	})
+
+	It("Should initialize nil map pointer in struct field", func() {
+		type S struct{ M *map[string]int }
+		s := &S{}
+		_, err := Reflect(s).MustStruct().Field("M").Map()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.M).ToNot(BeNil())
+		Expect(*s.M).ToNot(BeNil())
+	})
+
+	It("Should panic on MustMap() with non-map", func() {
+		Expect(func() { Reflect(22).MustMap() }).To(Panic())
+	})
+
+	It("Should return key and value types", func() {
+		m := Reflect(map[string]int{}).MustMap()
+		Expect(m.KeyType()).To(Equal(reflect.TypeOf("")))
+		Expect(m.ValueType()).To(Equal(reflect.TypeOf(0)))
+	})
+
+	It("Should .Get() and .Has()", func() {
+		m := Reflect(map[string]interface{}{"a": 1, "b": "x"}).MustMap()
+		Expect(m.Has("a")).To(BeTrue())
+		Expect(m.Has("c")).To(BeFalse())
+		Expect(m.Has(1)).To(BeFalse())
+		Expect(m.Get("a").Interface()).To(Equal(1))
+		Expect(m.Get("b").IsString()).To(BeTrue())
+		Expect(m.Get("c")).To(BeNil())
+		Expect(m.GetValue("b")).To(Equal("x"))
+		Expect(m.GetValue("c")).To(BeNil())
+	})
+
+	It("Should .Get() nil values", func() {
+		m := Reflect(map[string]interface{}{"a": nil, "b": 1}).MustMap()
+		Expect(m.Get("a")).ToNot(BeNil())
+		Expect(m.Get("a").IsNil()).To(BeTrue())
+		Expect(m.GetValue("a")).To(BeNil())
+
+		entries := m.SortedEntries()
+		Expect(entries[0].Key.Interface()).To(Equal("a"))
+		Expect(entries[0].Value.IsNil()).To(BeTrue())
+		Expect(entries[1].Value.Interface()).To(Equal(1))
+	})
+
+	It("Should .Set() values", func() {
+		data := map[string]interface{}{}
+		m := Reflect(data).MustMap()
+		Expect(m.SetValue("a", 1)).ToNot(HaveOccurred())
+		Expect(m.SetValue("b", nil)).ToNot(HaveOccurred())
+		Expect(data).To(Equal(map[string]interface{}{"a": 1, "b": nil}))
+	})
+
+	It("Should fail .Set() with type mismatch", func() {
+		m := Reflect(map[string]int{}).MustMap()
+		Expect(m.SetValue("a", "1")).To(HaveOccurred())
+		Expect(m.SetValue(1, 1)).To(HaveOccurred())
+	})
+
+	It("Should .Set() with conversion", func() {
+		data := map[int]int{}
+		m := Reflect(data).MustMap()
+		Expect(m.SetValue("1", "22", true)).ToNot(HaveOccurred())
+		Expect(data[1]).To(Equal(22))
+	})
+
+	It("Should fail .Set() on nil map", func() {
+		var data map[string]int
+		m := Reflect(data).MustMap()
+		Expect(m.SetValue("a", 1)).To(HaveOccurred())
+	})
+
+	It("Should .Delete() keys", func() {
+		data := map[string]int{"a": 1, "b": 2}
+		m := Reflect(data).MustMap()
+		Expect(m.Delete("a")).ToNot(HaveOccurred())
+		Expect(m.Delete("x")).ToNot(HaveOccurred())
+		Expect(data).To(Equal(map[string]int{"b": 2}))
+	})
+
+	It("Should return .Keys() and .Values()", func() {
+		m := Reflect(map[string]int{"a": 1, "b": 2}).MustMap()
+		Expect(m.Keys()).To(HaveLen(2))
+		Expect(m.Values()).To(HaveLen(2))
+	})
+
+	It("Should return .SortedKeys()", func() {
+		m := Reflect(map[int]string{10: "a", 2: "b", 5: "c"}).MustMap()
+		keys := []interface{}{}
+		for _, key := range m.SortedKeys() {
+			keys = append(keys, key.Interface())
+		}
+		Expect(keys).To(Equal([]interface{}{2, 5, 10}))
+	})
+
+	It("Should return .SortedEntries()", func() {
+		m := Reflect(map[string]interface{}{"b": 2, "a": 1}).MustMap()
+		entries := m.SortedEntries()
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0].Key.Interface()).To(Equal("a"))
+		Expect(entries[0].Value.Interface()).To(Equal(1))
+		Expect(entries[1].Key.Interface()).To(Equal("b"))
+	})
+
+	It("Should iterate with .EachSorted()", func() {
+		m := Reflect(map[string]int{"c": 3, "a": 1, "b": 2}).MustMap()
+		keys := ""
+		err := m.EachSorted(func(key, value *Reflector) error {
+			keys += key.Interface().(string)
+			return nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(keys).To(Equal("abc"))
+	})
+
+	It("Should create new map with .New()", func() {
+		m := Reflect(map[string]int{"a": 1}).MustMap().New()
+		Expect(m.Len()).To(Equal(0))
+		Expect(m.SetValue("x", 1)).ToNot(HaveOccurred())
+		Expect(m.Interface()).To(Equal(map[string]int{"x": 1}))
+	})
+})
@@ -0,0 +1,158 @@
+package reflector
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+)
+
+var (
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// newTarget allocates a new value of type typ, which may be a pointer type.
+// It returns a pointer to the allocated value, which can be used to call
+// methods with pointer receivers, and the result of type typ.
+func newTarget(typ reflect.Type) (ptr, result reflect.Value) {
+	if typ.Kind() == reflect.Ptr {
+		ptr = reflect.New(typ.Elem())
+		return ptr, ptr
+	}
+	ptr = reflect.New(typ)
+	return ptr, ptr.Elem()
+}
+
+// isTimeType returns true for time.Time and *time.Time, which have their own
+// conversion rules.
+func isTimeType(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ == timeType
+}
+
+// isMarshaler returns true if the type, or a pointer to it, implements
+// encoding.TextMarshaler or json.Marshaler.
+func isMarshaler(typ reflect.Type) bool {
+	ptr := reflect.PtrTo(typ)
+	return typ.Implements(textMarshalerType) || ptr.Implements(textMarshalerType) ||
+		typ.Implements(jsonMarshalerType) || ptr.Implements(jsonMarshalerType)
+}
+
+// marshalText converts r to a string with encoding.TextMarshaler, also
+// when MarshalText has a pointer receiver.
+// ok is false if the value does not implement the interface.
+func marshalText(r *Reflector) (text string, ok bool, err error) {
+	if !r.value.CanInterface() || isTimeType(r.Type()) {
+		return "", false, nil
+	}
+
+	m, ok := r.Interface().(encoding.TextMarshaler)
+	if !ok && r.Kind() != reflect.Ptr {
+		ptr := reflect.New(r.Type())
+		ptr.Elem().Set(r.value)
+		m, ok = ptr.Interface().(encoding.TextMarshaler)
+	}
+	if !ok {
+		return "", false, nil
+	}
+	if r.IsPtr() && r.IsNil() {
+		return "", true, newError(ERR_NIL_POINTER)
+	}
+
+	data, err := m.MarshalText()
+	if err != nil {
+		return "", true, &Error{
+			Code:   ERR_UNCONVERTABLE_TYPES,
+			Source: r.Type(),
+			Target: stringType,
+			Err:    err,
+		}
+	}
+	return string(data), true, nil
+}
+
+// unmarshalConvert converts strings with encoding.TextUnmarshaler and
+// []byte values with encoding.BinaryUnmarshaler, if typ implements them.
+// ok is false if no interface applies.
+func unmarshalConvert(r *Reflector, typ reflect.Type) (result interface{}, ok bool, err error) {
+	if isTimeType(typ) {
+		return nil, false, nil
+	}
+
+	ptr, value := newTarget(typ)
+	switch {
+	case r.IsString():
+		u, ok := ptr.Interface().(encoding.TextUnmarshaler)
+		if !ok {
+			return nil, false, nil
+		}
+		err = u.UnmarshalText([]byte(r.value.String()))
+
+	case r.Kind() == reflect.Slice && r.Type().Elem().Kind() == reflect.Uint8:
+		u, ok := ptr.Interface().(encoding.BinaryUnmarshaler)
+		if !ok {
+			return nil, false, nil
+		}
+		err = u.UnmarshalBinary(r.value.Bytes())
+
+	default:
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, true, &Error{
+			Code:   ERR_UNCONVERTABLE_TYPES,
+			Source: r.Type(),
+			Target: typ,
+			Err:    err,
+		}
+	}
+	return value.Interface(), true, nil
+}
+
+// unmarshalDecode lets target types implementing encoding.TextUnmarshaler or
+// json.Unmarshaler decode raw themselves.
+// Strings are passed to UnmarshalText if possible, other values are encoded
+// to JSON and passed to UnmarshalJSON.
+// ok is false if no interface applies.
+func unmarshalDecode(target reflect.Value, raw *Reflector) (ok bool, err error) {
+	typ := target.Type()
+	if isTimeType(typ) || !raw.value.CanInterface() {
+		return false, nil
+	}
+	if typ.Kind() == reflect.Ptr && raw.Type().AssignableTo(typ.Elem()) {
+		// Just needs a pointer.
+		return false, nil
+	}
+
+	ptr, value := newTarget(typ)
+	if raw.IsString() {
+		if result, ok, err := unmarshalConvert(raw, typ); ok {
+			if err != nil {
+				return true, err
+			}
+			return true, assignValue(target, Reflect(result), nil)
+		}
+	}
+
+	u, ok := ptr.Interface().(json.Unmarshaler)
+	if !ok {
+		return false, nil
+	}
+
+	data, err := json.Marshal(raw.Interface())
+	if err == nil {
+		err = u.UnmarshalJSON(data)
+	}
+	if err != nil {
+		return true, &Error{
+			Code:   ERR_UNCONVERTABLE_TYPES,
+			Source: raw.Type(),
+			Target: typ,
+			Err:    err,
+		}
+	}
+	return true, assignValue(target, &Reflector{value: value}, nil)
+}
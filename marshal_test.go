@@ -0,0 +1,177 @@
+package reflector_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"reflect"
+	"strings"
+
+	. "github.com/theduke/go-reflector"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// marshalID is an ID type that is serialized as "id-<n>".
+type marshalID struct {
+	n int
+}
+
+func (id marshalID) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("id-%v", id.n)), nil
+}
+
+func (id *marshalID) UnmarshalText(text []byte) error {
+	_, err := fmt.Sscanf(string(text), "id-%d", &id.n)
+	return err
+}
+
+func (id marshalID) String() string {
+	return "stringer"
+}
+
+// marshalBinary is decoded from its binary form.
+type marshalBinary struct {
+	data string
+}
+
+func (b *marshalBinary) UnmarshalBinary(data []byte) error {
+	b.data = strings.ToUpper(string(data))
+	return nil
+}
+
+// marshalPoint is decoded from JSON, as a list of coordinates.
+type marshalPoint struct {
+	X, Y int
+}
+
+func (p *marshalPoint) UnmarshalJSON(data []byte) error {
+	var coords []int
+	if err := json.Unmarshal(data, &coords); err != nil {
+		return err
+	}
+	if len(coords) != 2 {
+		return errors.New("expected two coordinates")
+	}
+	p.X, p.Y = coords[0], coords[1]
+	return nil
+}
+
+type marshalStruct struct {
+	ID     marshalID
+	IDPtr  *marshalID
+	IP     net.IP
+	Big    *big.Int
+	Point  marshalPoint
+	Points []marshalPoint
+}
+
+var _ = Describe("Marshaler interfaces", func() {
+	It("Should convert strings with TextUnmarshaler", func() {
+		Expect(R("id-5").ConvertTo(marshalID{})).To(Equal(marshalID{5}))
+		Expect(R("id-6").ConvertTo(&marshalID{})).To(Equal(&marshalID{6}))
+		Expect(R("10.0.0.1").ConvertTo(net.IP{})).To(Equal(net.ParseIP("10.0.0.1")))
+
+		_, err := R("nope").ConvertTo(marshalID{})
+		Expect(errors.Is(err, ErrUnconvertableTypes)).To(BeTrue())
+	})
+
+	It("Should convert bytes with BinaryUnmarshaler", func() {
+		Expect(R([]byte("abc")).ConvertTo(marshalBinary{})).To(Equal(marshalBinary{"ABC"}))
+	})
+
+	It("Should prefer TextMarshaler over Stringer", func() {
+		Expect(R(marshalID{3}).ConvertTo("")).To(Equal("id-3"))
+		Expect(R(&marshalID{4}).ConvertTo("")).To(Equal("id-4"))
+		Expect(R(net.ParseIP("10.0.0.1")).ConvertTo("")).To(Equal("10.0.0.1"))
+		Expect(R(*big.NewInt(42)).ConvertTo("")).To(Equal("42"))
+	})
+
+	It("Should round trip through ToMap and FromMap", func() {
+		s := marshalStruct{
+			ID:    marshalID{1},
+			IDPtr: &marshalID{2},
+			IP:    net.ParseIP("10.0.0.1"),
+			Big:   big.NewInt(99),
+		}
+
+		data := R(s).MustStruct().ToMapWith(&MapOptions{Zero: ZeroOmit})
+		Expect(data["ID"]).To(Equal(marshalID{1}))
+		Expect(data["Big"]).To(Equal(big.NewInt(99)))
+
+		loaded := &marshalStruct{}
+		Expect(R(loaded).MustStruct().FromMap(data)).ToNot(HaveOccurred())
+		Expect(*loaded).To(Equal(s))
+	})
+
+	It("Should keep pointers for marshalers with pointer receivers", func() {
+		encoded, err := json.Marshal(R(marshalStruct{Big: big.NewInt(42)}).MustStruct().ToMap(false, false))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(encoded)).To(ContainSubstring(`"Big":42`))
+
+		encoded, err = json.Marshal(R(struct{ N big.Int }{*big.NewInt(7)}).MustStruct().ToMap(false, false))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(encoded)).To(Equal(`{"N":7}`))
+	})
+
+	It("Should not point into the source for marshalers with pointer receivers", func() {
+		s := &struct{ N big.Int }{*big.NewInt(7)}
+		data := R(s).MustStruct().ToMap(false, false)
+		Expect(data["N"]).ToNot(BeIdenticalTo(&s.N))
+
+		n := data["N"].(*big.Int)
+		n.Neg(n)
+		Expect(n.Int64()).To(Equal(int64(-7)))
+		Expect(s.N.Int64()).To(Equal(int64(7)))
+	})
+
+	It("Should load strings into TextUnmarshaler fields", func() {
+		s := &marshalStruct{}
+		err := R(s).MustStruct().FromMap(map[string]interface{}{
+			"ID":    "id-7",
+			"IDPtr": "id-8",
+			"IP":    "10.0.0.2",
+			"Big":   "123456789012345678901234567890",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.ID).To(Equal(marshalID{7}))
+		Expect(s.IDPtr).To(Equal(&marshalID{8}))
+		Expect(s.IP.String()).To(Equal("10.0.0.2"))
+		Expect(s.Big.String()).To(Equal("123456789012345678901234567890"))
+	})
+
+	It("Should pass raw values to json.Unmarshaler", func() {
+		s := &marshalStruct{}
+		err := R(s).MustStruct().FromMap(map[string]interface{}{
+			"Big":    float64(12),
+			"Point":  []interface{}{1, 2},
+			"Points": []interface{}{[]interface{}{3, 4}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.Big.Int64()).To(Equal(int64(12)))
+		Expect(s.Point).To(Equal(marshalPoint{1, 2}))
+		Expect(s.Points).To(Equal([]marshalPoint{{3, 4}}))
+
+		err = R(s).MustStruct().FromMap(map[string]interface{}{
+			"Point": []interface{}{1},
+		})
+		Expect(errors.Is(err, ErrUnconvertableTypes)).To(BeTrue())
+		Expect(err.(*Error).Path).To(Equal("Point"))
+	})
+
+	It("Should stringify TextMarshaler map keys", func() {
+		type keyed struct {
+			ByID map[marshalID]int
+		}
+		data := R(keyed{ByID: map[marshalID]int{{1}: 1}}).MustStruct().ToMap(false, false)
+		Expect(data["ByID"]).To(Equal(map[string]interface{}{"id-1": 1}))
+
+		target := &keyed{}
+		Expect(R(target).MustStruct().FromMap(data)).ToNot(HaveOccurred())
+		Expect(target.ByID).To(Equal(map[marshalID]int{{1}: 1}))
+		Expect(reflect.TypeOf(target.ByID).Key()).To(Equal(reflect.TypeOf(marshalID{})))
+	})
+})
@@ -0,0 +1,260 @@
+package reflector
+
+import (
+	"reflect"
+)
+
+// MergeStrategy decides which fields are set by Merge.
+type MergeStrategy int
+
+const (
+	// MergeOverwrite overwrites fields with all non-zero source values.
+	MergeOverwrite MergeStrategy = iota
+	// MergeFillZero only sets fields that are zero.
+	MergeFillZero
+)
+
+// MergeOptions control Merge.
+// A nil *MergeOptions is equivalent to the zero value.
+type MergeOptions struct {
+	Strategy MergeStrategy
+
+	// AppendSlices appends source items to slices instead of replacing them.
+	AppendSlices bool
+
+	// MergeMaps adds source entries to maps instead of replacing them.
+	// Values of existing keys are merged recursively.
+	MergeMaps bool
+
+	// Shallow treats nested structs and struct pointers as single values
+	// instead of merging them field by field.
+	Shallow bool
+
+	// Tag and Naming map struct fields to names, which are used to match
+	// fields of different struct types and keys of maps. See MapOptions.
+	Tag    string
+	Naming NamingStrategy
+
+	// ConvertOptions control the conversion of values with different types.
+	ConvertOptions
+}
+
+type merger struct {
+	opts    *MergeOptions
+	mapOpts *MapOptions
+}
+
+// recurse returns true if values of type typ are merged field by field.
+func (m *merger) recurse(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return !m.opts.Shallow && typ.Kind() == reflect.Struct && typ != timeType && !isMarshaler(typ)
+}
+
+// convertCopy returns a deep copy of src, converted to typ.
+func (m *merger) convertCopy(src reflect.Value, typ reflect.Type) (reflect.Value, error) {
+	target := reflect.New(typ).Elem()
+	err := decodeValue(target, Reflect(src).DeepCopy(), m.mapOpts, 0)
+	return target, err
+}
+
+// merge merges src into the settable value dst.
+func (m *merger) merge(dst, src reflect.Value) error {
+	for src.Kind() == reflect.Ptr || src.Kind() == reflect.Interface {
+		if src.IsNil() {
+			return nil
+		}
+		src = src.Elem()
+	}
+	if !src.IsValid() || src.IsZero() {
+		return nil
+	}
+
+	if m.recurse(dst.Type()) && (src.Kind() == reflect.Struct || src.Kind() == reflect.Map) {
+		if dst.Kind() == reflect.Ptr {
+			if dst.IsNil() {
+				dst.Set(reflect.New(dst.Type().Elem()))
+			}
+			dst = dst.Elem()
+		}
+		return m.mergeStruct(dst, src)
+	}
+
+	switch {
+	case dst.Kind() == reflect.Slice && m.opts.AppendSlices && (src.Kind() == reflect.Slice || src.Kind() == reflect.Array):
+		items, err := m.convertCopy(src, dst.Type())
+		if err != nil {
+			return err
+		}
+		dst.Set(reflect.AppendSlice(dst, items))
+		return nil
+
+	case dst.Kind() == reflect.Map && m.opts.MergeMaps && src.Kind() == reflect.Map:
+		return m.mergeMap(dst, src)
+	}
+
+	if m.opts.Strategy == MergeFillZero && !dst.IsZero() {
+		return nil
+	}
+	value, err := m.convertCopy(src, dst.Type())
+	if err != nil {
+		return err
+	}
+	dst.Set(value)
+	return nil
+}
+
+func (m *merger) mergeMap(dst, src reflect.Value) error {
+	if dst.IsNil() {
+		dst.Set(reflect.MakeMapWithSize(dst.Type(), src.Len()))
+	}
+
+	iter := src.MapRange()
+	for iter.Next() {
+		path := mapKeyString(iter.Key())
+		key, err := m.convertCopy(iter.Key(), dst.Type().Key())
+		if err != nil {
+			return prefixPath(err, path)
+		}
+
+		item := reflect.New(dst.Type().Elem()).Elem()
+		if existing := dst.MapIndex(key); existing.IsValid() {
+			item.Set(existing)
+		}
+		if err := m.merge(item, iter.Value()); err != nil {
+			return prefixPath(err, path)
+		}
+		dst.SetMapIndex(key, item)
+	}
+	return nil
+}
+
+// mergeStruct merges the struct or map src into the struct dst.
+func (m *merger) mergeStruct(dst, src reflect.Value) error {
+	fields := make(map[string]reflect.Value)
+	if src.Kind() == reflect.Map {
+		iter := src.MapRange()
+		for iter.Next() {
+			fields[mapKeyString(iter.Key())] = iter.Value()
+		}
+	} else {
+		m.sourceFields(src, fields)
+	}
+	return m.mergeFields(dst, fields)
+}
+
+// sourceFields adds the fields of the struct src to fields, by name.
+// Fields of inlined structs are added as well, unless they are shadowed.
+func (m *merger) sourceFields(src reflect.Value, fields map[string]reflect.Value) {
+	s, err := newStructReflector(&Reflector{value: src})
+	if err != nil {
+		return
+	}
+
+	inlined := make([]reflect.Value, 0)
+	for _, field := range s.mapFields(m.mapOpts) {
+		if field.inline {
+			inlined = append(inlined, field.value.Value())
+		} else {
+			fields[field.name] = field.value.Value()
+		}
+	}
+
+	for _, inline := range inlined {
+		if inline.Kind() == reflect.Ptr {
+			if inline.IsNil() {
+				continue
+			}
+			inline = inline.Elem()
+		}
+		nested := make(map[string]reflect.Value)
+		m.sourceFields(inline, nested)
+		for name, value := range nested {
+			if _, ok := fields[name]; !ok {
+				fields[name] = value
+			}
+		}
+	}
+}
+
+func (m *merger) mergeFields(dst reflect.Value, src map[string]reflect.Value) error {
+	s, err := newStructReflector(&Reflector{value: dst})
+	if err != nil {
+		return err
+	}
+
+	for _, field := range s.mapFields(m.mapOpts) {
+		value := field.value.Value()
+		if !field.inline {
+			if srcValue, ok := src[field.name]; ok {
+				if err := m.merge(value, srcValue); err != nil {
+					return prefixPath(err, field.name)
+				}
+			}
+			continue
+		}
+
+		if value.Kind() != reflect.Ptr {
+			if err := m.mergeFields(value, src); err != nil {
+				return prefixPath(err, field.info.Name)
+			}
+			continue
+		}
+
+		// Nil struct pointers are only allocated if anything was merged.
+		nested := value
+		if value.IsNil() {
+			nested = reflect.New(value.Type().Elem())
+		}
+		if err := m.mergeFields(nested.Elem(), src); err != nil {
+			return prefixPath(err, field.info.Name)
+		}
+		if value.IsNil() && !nested.Elem().IsZero() {
+			value.Set(nested)
+		}
+	}
+	return nil
+}
+
+// Merge merges src into the struct, which must be addressable.
+// src may be a struct or struct pointer of the same or another type, or a
+// map with string keys. Fields are matched by name, or by the tag configured
+// in opts.
+// By default, all non-zero source values overwrite the fields, nested and
+// embedded structs are merged field by field, and slices and maps are
+// replaced. Zero source values never change a field.
+// Merged values are deep copies, so src is never aliased.
+func (r *StructReflector) Merge(src interface{}, opts *MergeOptions) error {
+	if opts == nil {
+		opts = &MergeOptions{}
+	}
+	dst := r.structItem.Value()
+	if !dst.CanSet() {
+		return &Error{Code: ERR_UNSETTABLE_VALUE, Target: dst.Type()}
+	}
+
+	v := reflectValue(src)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Kind() != reflect.Struct && v.Kind() != reflect.Map {
+		return &Error{Code: ERR_TYPE_MISMATCH, Source: v.Type(), Target: dst.Type()}
+	}
+
+	m := &merger{
+		opts: opts,
+		mapOpts: &MapOptions{
+			Tag:            opts.Tag,
+			Naming:         opts.Naming,
+			ConvertOptions: opts.ConvertOptions,
+		},
+	}
+	return m.mergeStruct(dst, v)
+}
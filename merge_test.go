@@ -0,0 +1,152 @@
+package reflector_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/theduke/go-reflector"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type mergeLogging struct {
+	Level string
+	Debug bool
+}
+
+type mergeServer struct {
+	Host    string
+	Port    int
+	Timeout time.Duration
+}
+
+type mergeConfig struct {
+	mergeLogging
+	Name    string
+	Server  mergeServer
+	DB      *mergeServer
+	Plugins []string
+	Labels  map[string]string
+}
+
+type mergeFlags struct {
+	Name  string `cfg:"name"`
+	Port  int    `cfg:"server_port"`
+	Debug bool   `cfg:"debug"`
+}
+
+var _ = Describe("Merge", func() {
+	var cfg *mergeConfig
+
+	BeforeEach(func() {
+		cfg = &mergeConfig{
+			mergeLogging: mergeLogging{Level: "info"},
+			Name:         "app",
+			Server:       mergeServer{Host: "localhost", Port: 80},
+			Plugins:      []string{"a"},
+			Labels:       map[string]string{"env": "dev"},
+		}
+	})
+
+	It("Should overwrite fields with non-zero values", func() {
+		src := &mergeConfig{
+			mergeLogging: mergeLogging{Debug: true},
+			Server:       mergeServer{Port: 8080},
+			DB:           &mergeServer{Host: "db"},
+			Plugins:      []string{"b"},
+			Labels:       map[string]string{"team": "x"},
+		}
+		Expect(R(cfg).MustStruct().Merge(src, nil)).To(Succeed())
+
+		Expect(cfg).To(Equal(&mergeConfig{
+			mergeLogging: mergeLogging{Level: "info", Debug: true},
+			Name:         "app",
+			Server:       mergeServer{Host: "localhost", Port: 8080},
+			DB:           &mergeServer{Host: "db"},
+			Plugins:      []string{"b"},
+			Labels:       map[string]string{"team": "x"},
+		}))
+
+		// Merged values are copies.
+		src.DB.Host = "changed"
+		src.Plugins[0] = "changed"
+		Expect(cfg.DB.Host).To(Equal("db"))
+		Expect(cfg.Plugins).To(Equal([]string{"b"}))
+	})
+
+	It("Should only fill zero fields", func() {
+		defaults := mergeConfig{
+			mergeLogging: mergeLogging{Level: "warn", Debug: true},
+			Name:         "default",
+			Server:       mergeServer{Host: "0.0.0.0", Port: 1, Timeout: time.Second},
+			DB:           &mergeServer{Port: 5432},
+		}
+		Expect(R(cfg).MustStruct().Merge(defaults, &MergeOptions{Strategy: MergeFillZero})).To(Succeed())
+
+		Expect(cfg.Level).To(Equal("info"))
+		Expect(cfg.Debug).To(BeTrue())
+		Expect(cfg.Name).To(Equal("app"))
+		Expect(cfg.Server).To(Equal(mergeServer{Host: "localhost", Port: 80, Timeout: time.Second}))
+		Expect(cfg.DB).To(Equal(&mergeServer{Port: 5432}))
+	})
+
+	It("Should append slices and merge maps", func() {
+		src := &mergeConfig{
+			Plugins: []string{"b"},
+			Labels:  map[string]string{"env": "prod", "team": "x"},
+		}
+		Expect(R(cfg).MustStruct().Merge(src, &MergeOptions{AppendSlices: true, MergeMaps: true})).To(Succeed())
+		Expect(cfg.Plugins).To(Equal([]string{"a", "b"}))
+		Expect(cfg.Labels).To(Equal(map[string]string{"env": "prod", "team": "x"}))
+
+		Expect(R(cfg).MustStruct().Merge(map[string]interface{}{
+			"Labels": map[string]interface{}{"env": "stage", "new": "y"},
+		}, &MergeOptions{MergeMaps: true, Strategy: MergeFillZero})).To(Succeed())
+		Expect(cfg.Labels).To(Equal(map[string]string{"env": "prod", "team": "x", "new": "y"}))
+	})
+
+	It("Should replace nested structs when shallow", func() {
+		src := &mergeConfig{Server: mergeServer{Port: 8080}}
+		Expect(R(cfg).MustStruct().Merge(src, &MergeOptions{Shallow: true})).To(Succeed())
+		Expect(cfg.Server).To(Equal(mergeServer{Port: 8080}))
+	})
+
+	It("Should merge maps and other struct types", func() {
+		err := R(cfg).MustStruct().Merge(map[string]interface{}{
+			"Name":   "env",
+			"Level":  "debug",
+			"Server": map[string]interface{}{"Timeout": "5s"},
+			"DB":     map[string]interface{}{"Port": "5432"},
+		}, &MergeOptions{ConvertOptions: ConvertOptions{Mode: ConvertLenient}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Name).To(Equal("env"))
+		Expect(cfg.Level).To(Equal("debug"))
+		Expect(cfg.Server).To(Equal(mergeServer{Host: "localhost", Port: 80, Timeout: 5 * time.Second}))
+		Expect(cfg.DB).To(Equal(&mergeServer{Port: 5432}))
+
+		flags := &mergeFlags{Name: "flag", Debug: true}
+		Expect(R(cfg).MustStruct().Merge(flags, nil)).To(Succeed())
+		Expect(cfg.Name).To(Equal("flag"))
+		Expect(cfg.Debug).To(BeTrue())
+		Expect(cfg.Server.Port).To(Equal(80))
+	})
+
+	It("Should match fields by tag", func() {
+		flags := &mergeFlags{}
+		Expect(R(flags).MustStruct().Merge(map[string]interface{}{
+			"name":        "tagged",
+			"server_port": 9000,
+		}, &MergeOptions{Tag: "cfg"})).To(Succeed())
+		Expect(flags).To(Equal(&mergeFlags{Name: "tagged", Port: 9000}))
+	})
+
+	It("Should report errors", func() {
+		err := R(cfg).MustStruct().Merge(map[string]interface{}{"Server": map[string]interface{}{"Port": "x"}}, nil)
+		Expect(errors.Is(err, ErrTypeMismatch)).To(BeTrue())
+		Expect(err.(*Error).Path).To(Equal("Server.Port"))
+
+		Expect(errors.Is(R(cfg).MustStruct().Merge(5, nil), ErrTypeMismatch)).To(BeTrue())
+		Expect(errors.Is(R(*cfg).MustStruct().Merge(cfg, nil), ErrUnsettableValue)).To(BeTrue())
+	})
+})
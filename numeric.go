@@ -0,0 +1,213 @@
+package reflector
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// convertNumber converts a number or numeric string to the numeric type typ,
+// failing if the value can not be represented exactly.
+// See ConvertStrict.
+func convertNumber(r *Reflector, typ reflect.Type) (interface{}, error) {
+	target := reflect.New(typ).Elem()
+
+	var value string
+	var err error
+	switch {
+	case r.IsString():
+		value = strings.TrimSpace(r.value.String())
+		err = setNumberString(target, value)
+	case isIntKind(r.Kind()):
+		value = strconv.FormatInt(r.value.Int(), 10)
+		err = setNumberInt(target, r.value.Int())
+	case isUintKind(r.Kind()):
+		value = strconv.FormatUint(r.value.Uint(), 10)
+		err = setNumberUint(target, r.value.Uint())
+	default:
+		value = strconv.FormatFloat(r.value.Float(), 'g', -1, 64)
+		err = setNumberFloat(target, r.value.Float())
+	}
+
+	if err != nil {
+		code := ERR_UNCONVERTABLE_TYPES
+		var e *Error
+		if errors.As(err, &e) {
+			code, err = e.Code, e.Err
+		}
+		return nil, &Error{
+			Code:    code,
+			Source:  r.Type(),
+			Target:  typ,
+			Message: value,
+			Err:     err,
+		}
+	}
+	return target.Interface(), nil
+}
+
+func isIntKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+func isUintKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return false
+}
+
+// setNumberString parses str with the bit size of target.
+// Integer targets also accept floats without a fraction, like "1e3".
+func setNumberString(target reflect.Value, str string) error {
+	kind := target.Kind()
+	bits := target.Type().Bits()
+
+	switch {
+	case isIntKind(kind):
+		n, err := strconv.ParseInt(str, 10, bits)
+		if err == nil {
+			target.SetInt(n)
+			return nil
+		}
+		if errors.Is(err, strconv.ErrRange) {
+			return &Error{Code: ERR_NUMERIC_OVERFLOW, Err: err}
+		}
+
+	case isUintKind(kind):
+		n, err := strconv.ParseUint(str, 10, bits)
+		if err == nil {
+			target.SetUint(n)
+			return nil
+		}
+		if errors.Is(err, strconv.ErrRange) {
+			return &Error{Code: ERR_NUMERIC_OVERFLOW, Err: err}
+		}
+		if _, intErr := strconv.ParseInt(str, 10, 64); intErr == nil || errors.Is(intErr, strconv.ErrRange) {
+			// Negative integer.
+			return &Error{Code: ERR_NUMERIC_OVERFLOW}
+		}
+
+	default:
+		// Integers must be represented exactly by the float.
+		if n, err := strconv.ParseInt(str, 10, 64); err == nil {
+			return setNumberInt(target, n)
+		}
+	}
+
+	f, err := strconv.ParseFloat(str, bits)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return &Error{Code: ERR_NUMERIC_OVERFLOW, Err: err}
+		}
+		return err
+	}
+	if kind == reflect.Float32 || kind == reflect.Float64 {
+		target.SetFloat(f)
+		return nil
+	}
+	if math.Abs(f) >= 1<<53 {
+		// Parsing may already have rounded the number.
+		return newError(ERR_LOSSY_CONVERSION)
+	}
+	return setNumberFloat(target, f)
+}
+
+func setNumberInt(target reflect.Value, n int64) error {
+	kind := target.Kind()
+	switch {
+	case isIntKind(kind):
+		if target.OverflowInt(n) {
+			return newError(ERR_NUMERIC_OVERFLOW)
+		}
+		target.SetInt(n)
+	case isUintKind(kind):
+		if n < 0 || target.OverflowUint(uint64(n)) {
+			return newError(ERR_NUMERIC_OVERFLOW)
+		}
+		target.SetUint(uint64(n))
+	default:
+		f := float64(n)
+		if kind == reflect.Float32 {
+			f = float64(float32(f))
+		}
+		// Floats can only represent integers up to 2^53 exactly.
+		if f >= math.MaxInt64 || int64(f) != n {
+			return newError(ERR_LOSSY_CONVERSION)
+		}
+		target.SetFloat(f)
+	}
+	return nil
+}
+
+func setNumberUint(target reflect.Value, n uint64) error {
+	kind := target.Kind()
+	switch {
+	case isIntKind(kind):
+		if n > math.MaxInt64 || target.OverflowInt(int64(n)) {
+			return newError(ERR_NUMERIC_OVERFLOW)
+		}
+		target.SetInt(int64(n))
+	case isUintKind(kind):
+		if target.OverflowUint(n) {
+			return newError(ERR_NUMERIC_OVERFLOW)
+		}
+		target.SetUint(n)
+	default:
+		f := float64(n)
+		if kind == reflect.Float32 {
+			f = float64(float32(f))
+		}
+		if f >= math.MaxUint64 || uint64(f) != n {
+			return newError(ERR_LOSSY_CONVERSION)
+		}
+		target.SetFloat(f)
+	}
+	return nil
+}
+
+func setNumberFloat(target reflect.Value, f float64) error {
+	kind := target.Kind()
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		if kind == reflect.Float32 || kind == reflect.Float64 {
+			target.SetFloat(f)
+			return nil
+		}
+		return newError(ERR_NUMERIC_OVERFLOW)
+	}
+
+	switch {
+	case isIntKind(kind):
+		if f != math.Trunc(f) {
+			return newError(ERR_LOSSY_CONVERSION)
+		}
+		if f < math.MinInt64 || f >= math.MaxInt64 || target.OverflowInt(int64(f)) {
+			return newError(ERR_NUMERIC_OVERFLOW)
+		}
+		target.SetInt(int64(f))
+	case isUintKind(kind):
+		if f != math.Trunc(f) {
+			return newError(ERR_LOSSY_CONVERSION)
+		}
+		if f < 0 || f >= math.MaxUint64 || target.OverflowUint(uint64(f)) {
+			return newError(ERR_NUMERIC_OVERFLOW)
+		}
+		target.SetUint(uint64(f))
+	default:
+		if target.OverflowFloat(f) {
+			return newError(ERR_NUMERIC_OVERFLOW)
+		}
+		if kind == reflect.Float32 && float64(float32(f)) != f {
+			return newError(ERR_LOSSY_CONVERSION)
+		}
+		target.SetFloat(f)
+	}
+	return nil
+}
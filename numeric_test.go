@@ -0,0 +1,86 @@
+package reflector_test
+
+import (
+	"errors"
+	"math"
+	"reflect"
+
+	. "github.com/theduke/go-reflector"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Strict numeric conversion", func() {
+	strict := &ConvertOptions{Mode: ConvertStrict}
+
+	convert := func(value, target interface{}) (interface{}, error) {
+		return R(value).ConvertToTypeWith(reflect.TypeOf(target), strict)
+	}
+
+	expectError := func(value, target interface{}, sentinel error) {
+		_, err := convert(value, target)
+		Expect(errors.Is(err, sentinel)).To(BeTrue(), "%v => %T: %v", value, target, err)
+	}
+
+	It("Should parse integers without losing precision", func() {
+		Expect(convert("9007199254740993", int64(0))).To(Equal(int64(9007199254740993)))
+		Expect(convert(" 255 ", uint8(0))).To(Equal(uint8(255)))
+		Expect(convert("-128", int8(0))).To(Equal(int8(-128)))
+		Expect(convert("1e3", 0)).To(Equal(1000))
+		Expect(convert("2.5", float32(0))).To(Equal(float32(2.5)))
+
+		expectError("9007199254740993", float64(0), ErrLossyConversion)
+		expectError("9007199254740993.0", int64(0), ErrLossyConversion)
+	})
+
+	It("Should reject overflows", func() {
+		expectError("300", uint8(0), ErrNumericOverflow)
+		expectError("-1", uint(0), ErrNumericOverflow)
+		expectError("128", int8(0), ErrNumericOverflow)
+		expectError("1e400", float64(0), ErrNumericOverflow)
+		expectError(300, uint8(0), ErrNumericOverflow)
+		expectError(-1, uint(0), ErrNumericOverflow)
+		expectError(uint64(math.MaxUint64), int64(0), ErrNumericOverflow)
+		expectError(1e20, int64(0), ErrNumericOverflow)
+		expectError(-1.0, uint(0), ErrNumericOverflow)
+		expectError(math.Inf(1), 0, ErrNumericOverflow)
+		expectError(math.MaxFloat64, float32(0), ErrNumericOverflow)
+	})
+
+	It("Should reject lossy conversions", func() {
+		expectError(1.5, 0, ErrLossyConversion)
+		expectError("1.5", 0, ErrLossyConversion)
+		expectError(int64(1<<53+1), float64(0), ErrLossyConversion)
+		expectError(0.1, float32(0), ErrLossyConversion)
+	})
+
+	It("Should convert exact values", func() {
+		Expect(convert(2.0, 0)).To(Equal(2))
+		Expect(convert(int64(200), uint8(0))).To(Equal(uint8(200)))
+		Expect(convert(uint(7), int16(0))).To(Equal(int16(7)))
+		Expect(convert(int64(1<<53), float64(0))).To(Equal(float64(1 << 53)))
+		Expect(convert(0.5, float32(0))).To(Equal(float32(0.5)))
+	})
+
+	It("Should report invalid numbers", func() {
+		expectError("abc", 0, ErrUnconvertableTypes)
+	})
+
+	It("Should keep the lenient behaviour", func() {
+		Expect(R("300").ConvertTo(uint8(0))).To(Equal(uint8(44)))
+		Expect(R(1.5).ConvertTo(0)).To(Equal(1))
+	})
+
+	It("Should be used when setting values", func() {
+		s := &struct{ Small int8 }{}
+		err := R(s).MustStruct().FromMapWith(map[string]interface{}{"Small": 1000}, &MapOptions{
+			ConvertOptions: ConvertOptions{Mode: ConvertStrict},
+		})
+		Expect(errors.Is(err, ErrNumericOverflow)).To(BeTrue())
+		Expect(err.(*Error).Path).To(Equal("Small"))
+
+		Expect(R(s).MustStruct().SetFieldWith("Small", "12", strict)).ToNot(HaveOccurred())
+		Expect(s.Small).To(Equal(int8(12)))
+	})
+})
@@ -0,0 +1,222 @@
+package reflector
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ConversionMode controls if and how values are converted when they are
+// assigned to a value of a different type.
+type ConversionMode int
+
+const (
+	// ConvertNone requires values to be of the exact target type.
+	ConvertNone ConversionMode = iota
+	// ConvertLenient converts values with ConvertToType.
+	// Numbers are converted like Go conversions do, so they may be
+	// truncated or wrap around.
+	ConvertLenient
+	// ConvertStrict converts values like ConvertLenient, but numbers and
+	// numeric strings are only converted if no information is lost.
+	// Overflows, including negative numbers converted to unsigned types,
+	// fail with ERR_NUMERIC_OVERFLOW, and fractions converted to integers or
+	// numbers that can't be represented exactly fail with ERR_LOSSY_CONVERSION.
+	ConvertStrict
+)
+
+// ConvertOptions control type conversions.
+// A nil *ConvertOptions is equivalent to the zero value.
+type ConvertOptions struct {
+	// Mode controls if values are converted when setting values.
+	// ConvertToTypeWith always converts, but respects ConvertStrict.
+	Mode ConversionMode
+
+	// TimeFormat is the layout used when converting time.Time values to
+	// strings. If empty, time.Time.String() is used.
+	// ToMapWith converts time.Time values to strings if TimeFormat is set.
+	TimeFormat string
+
+	// TimeLayouts are the layouts that are tried in order when parsing
+	// strings into time.Time. If empty, DefaultTimeLayouts is used.
+	TimeLayouts []string
+
+	// TimeLocation is used for layouts without time zone information and
+	// for Unix timestamps. Defaults to UTC.
+	TimeLocation *time.Location
+
+	// UnixPrecision is the unit of numbers converted to and from time.Time.
+	UnixPrecision UnixPrecision
+
+	// Converters holds custom converters that are consulted before
+	// DefaultConverters and the builtin conversion rules.
+	Converters *ConverterRegistry
+}
+
+// lenient returns a copy of the options with conversion enabled.
+// ConvertStrict is kept.
+func (o *ConvertOptions) lenient() *ConvertOptions {
+	opts := ConvertOptions{}
+	if o != nil {
+		opts = *o
+	}
+	if opts.Mode == ConvertNone {
+		opts.Mode = ConvertLenient
+	}
+	return &opts
+}
+
+// convertMode translates the legacy convert ...bool argument.
+func convertMode(convert []bool) ConversionMode {
+	if len(convert) > 0 && convert[0] {
+		return ConvertLenient
+	}
+	return ConvertNone
+}
+
+func convertOptions(convert []bool) *ConvertOptions {
+	return &ConvertOptions{Mode: convertMode(convert)}
+}
+
+func (o *ConvertOptions) converts() bool {
+	return o != nil && o.Mode != ConvertNone
+}
+
+// ZeroPolicy controls how zero values are handled by ToMapWith and FromMapWith.
+type ZeroPolicy int
+
+const (
+	// ZeroNil stores zero values as nil in ToMapWith.
+	// FromMapWith skips zero values.
+	// This is the default.
+	ZeroNil ZeroPolicy = iota
+	// ZeroKeep stores zero values as they are in ToMapWith.
+	// FromMapWith assigns zero values, which resets fields.
+	ZeroKeep
+	// ZeroOmit omits zero values in ToMapWith.
+	// FromMapWith skips zero values.
+	ZeroOmit
+	// EmptyOmit omits zero values and empty slices, maps and arrays in ToMapWith.
+	// FromMapWith skips zero values.
+	EmptyOmit
+)
+
+// zeroPolicy translates the legacy omitZero and omitEmpty flags.
+func zeroPolicy(omitZero, omitEmpty bool) ZeroPolicy {
+	if omitEmpty {
+		return EmptyOmit
+	} else if omitZero {
+		return ZeroOmit
+	}
+	return ZeroNil
+}
+
+// NamingStrategy maps a Go field name to a map key.
+// It is only applied to fields without an explicit name in the struct tag.
+type NamingStrategy func(fieldName string) string
+
+// MapOptions control ToMapWith and FromMapWith.
+// A nil *MapOptions is equivalent to the zero value.
+type MapOptions struct {
+	// Tag is the struct tag to read field names and options from,
+	// like "json" or "db". See ToTaggedMap.
+	Tag string
+
+	// Naming maps field names to keys for fields without a tag name.
+	Naming NamingStrategy
+
+	// MaxDepth limits the number of nested struct levels that are converted.
+	// Deeper structs are kept as they are by ToMapWith, and skipped by FromMapWith.
+	// 0 means unlimited.
+	MaxDepth int
+
+	// IncludeUnexported includes unexported fields in ToMapWith.
+	// They can not be set, so FromMapWith ignores them.
+	IncludeUnexported bool
+
+	// ErrorOnUnknown makes FromMapWith return an ERR_UNKNOWN_FIELD error
+	// for keys that don't match any field.
+	ErrorOnUnknown bool
+
+	// Strict makes FromMapWith report all problems at once instead of
+	// stopping at the first one: unknown keys, missing required fields and
+	// conversion failures are returned as a *MultiError.
+	// Required fields are only checked in strict mode, including those of
+	// nested structs that are missing entirely.
+	// Strict implies ErrorOnUnknown.
+	Strict bool
+
+	// Zero controls how zero values are handled.
+	Zero ZeroPolicy
+
+	ConvertOptions
+}
+
+func (o *MapOptions) depthExceeded(depth int) bool {
+	return o.MaxDepth > 0 && depth >= o.MaxDepth
+}
+
+// splitWords splits a Go identifier into words, keeping initialisms together.
+// For example "HTTPServerID" becomes "HTTP", "Server", "ID".
+func splitWords(name string) []string {
+	runes := []rune(name)
+	words := make([]string, 0)
+	start := 0
+
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+		split := false
+
+		if cur == '_' || cur == '-' {
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i + 1
+			continue
+		}
+
+		if unicode.IsUpper(cur) {
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) {
+				// fooBar
+				split = true
+			} else if unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+				// HTTPServer
+				split = true
+			}
+		}
+
+		if split && i > start {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// SnakeCase converts field names like "HTTPServerID" to "http_server_id".
+func SnakeCase(fieldName string) string {
+	return strings.ToLower(strings.Join(splitWords(fieldName), "_"))
+}
+
+// KebabCase converts field names like "HTTPServerID" to "http-server-id".
+func KebabCase(fieldName string) string {
+	return strings.ToLower(strings.Join(splitWords(fieldName), "-"))
+}
+
+// LowerCamelCase converts field names like "HTTPServerID" to "httpServerID".
+func LowerCamelCase(fieldName string) string {
+	words := splitWords(fieldName)
+	if len(words) == 0 {
+		return fieldName
+	}
+	words[0] = strings.ToLower(words[0])
+	return strings.Join(words, "")
+}
+
+// LowerCase converts field names like "HTTPServerID" to "httpserverid".
+func LowerCase(fieldName string) string {
+	return strings.ToLower(fieldName)
+}
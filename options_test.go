@@ -0,0 +1,186 @@
+package reflector_test
+
+import (
+	"errors"
+	"reflect"
+	"time"
+
+	. "github.com/theduke/go-reflector"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type optionsInner struct {
+	Value int
+}
+
+type optionsStruct struct {
+	HTTPServerID string
+	Created      time.Time
+	Inner        optionsInner
+	InnerPtr     *optionsInner
+	hidden       int
+}
+
+var _ = Describe("Options", func() {
+	Describe("Naming strategies", func() {
+		It("Should convert names", func() {
+			Expect(SnakeCase("HTTPServerID")).To(Equal("http_server_id"))
+			Expect(SnakeCase("FieldName2")).To(Equal("field_name2"))
+			Expect(KebabCase("HTTPServerID")).To(Equal("http-server-id"))
+			Expect(LowerCamelCase("HTTPServerID")).To(Equal("httpServerID"))
+			Expect(LowerCamelCase("Name")).To(Equal("name"))
+			Expect(LowerCase("HTTPServerID")).To(Equal("httpserverid"))
+		})
+	})
+
+	Describe("ToMapWith", func() {
+		var s optionsStruct
+
+		BeforeEach(func() {
+			s = optionsStruct{
+				HTTPServerID: "id",
+				Created:      time.Date(2015, 11, 4, 10, 0, 0, 0, time.UTC),
+				Inner:        optionsInner{Value: 1},
+				hidden:       5,
+			}
+		})
+
+		It("Should apply the naming strategy", func() {
+			data := R(s).MustStruct().ToMapWith(&MapOptions{
+				Naming: SnakeCase,
+				Zero:   ZeroOmit,
+			})
+			Expect(data).To(HaveKey("http_server_id"))
+			Expect(data["inner"]).To(Equal(map[string]interface{}{"value": 1}))
+		})
+
+		It("Should keep time.Time values", func() {
+			data := R(s).MustStruct().ToMapWith(nil)
+			Expect(data["Created"]).To(Equal(s.Created))
+		})
+
+		It("Should format time.Time values", func() {
+			data := R(s).MustStruct().ToMapWith(&MapOptions{
+				ConvertOptions: ConvertOptions{TimeFormat: time.RFC3339},
+			})
+			Expect(data["Created"]).To(Equal("2015-11-04T10:00:00Z"))
+		})
+
+		It("Should limit the depth", func() {
+			data := R(s).MustStruct().ToMapWith(&MapOptions{MaxDepth: 1})
+			Expect(data["Inner"]).To(Equal(optionsInner{Value: 1}))
+		})
+
+		It("Should skip unexported fields by default", func() {
+			data := R(s).MustStruct().ToMapWith(nil)
+			Expect(data).ToNot(HaveKey("hidden"))
+		})
+
+		It("Should include unexported fields", func() {
+			data := R(s).MustStruct().ToMapWith(&MapOptions{IncludeUnexported: true})
+			Expect(data["hidden"]).To(Equal(5))
+
+			data = R(struct{ inner *optionsInner }{&optionsInner{Value: 2}}).MustStruct().ToMapWith(&MapOptions{IncludeUnexported: true})
+			Expect(data["inner"]).To(Equal(map[string]interface{}{"Value": 2}))
+		})
+
+		It("Should apply zero value policies", func() {
+			s := optionsStruct{}
+
+			data := R(s).MustStruct().ToMapWith(&MapOptions{Zero: ZeroNil})
+			Expect(data["HTTPServerID"]).To(BeNil())
+			Expect(data["Inner"]).To(BeNil())
+
+			data = R(s).MustStruct().ToMapWith(&MapOptions{Zero: ZeroKeep})
+			Expect(data["HTTPServerID"]).To(Equal(""))
+			Expect(data["Inner"]).To(Equal(map[string]interface{}{"Value": 0}))
+			Expect(data).To(HaveKey("InnerPtr"))
+
+			data = R(s).MustStruct().ToMapWith(&MapOptions{Zero: ZeroOmit})
+			Expect(data).To(BeEmpty())
+		})
+	})
+
+	Describe("FromMapWith", func() {
+		It("Should apply the naming strategy", func() {
+			s := &optionsStruct{}
+			err := R(s).MustStruct().FromMapWith(map[string]interface{}{
+				"http_server_id": "x",
+				"inner_ptr":      map[string]interface{}{"value": 2},
+			}, &MapOptions{Naming: SnakeCase})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(s.HTTPServerID).To(Equal("x"))
+			Expect(s.InnerPtr.Value).To(Equal(2))
+		})
+
+		It("Should report unknown keys", func() {
+			s := &optionsStruct{}
+			err := R(s).MustStruct().FromMapWith(map[string]interface{}{
+				"Inner": map[string]interface{}{"Nope": 2},
+			}, &MapOptions{ErrorOnUnknown: true})
+			Expect(errors.Is(err, ErrUnknownField)).To(BeTrue())
+			Expect(err.(*Error).Path).To(Equal("Inner.Nope"))
+		})
+
+		It("Should ignore unexported fields", func() {
+			s := &optionsStruct{hidden: 1}
+			err := R(s).MustStruct().FromMapWith(map[string]interface{}{
+				"hidden": 2,
+			}, &MapOptions{IncludeUnexported: true, ErrorOnUnknown: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(s.hidden).To(Equal(1))
+		})
+
+		It("Should assign zero values with ZeroKeep", func() {
+			s := &optionsStruct{HTTPServerID: "x"}
+			data := map[string]interface{}{"HTTPServerID": ""}
+
+			Expect(R(s).MustStruct().FromMapWith(data, nil)).ToNot(HaveOccurred())
+			Expect(s.HTTPServerID).To(Equal("x"))
+
+			Expect(R(s).MustStruct().FromMapWith(data, &MapOptions{Zero: ZeroKeep})).ToNot(HaveOccurred())
+			Expect(s.HTTPServerID).To(Equal(""))
+		})
+
+		It("Should convert values", func() {
+			s := &optionsStruct{}
+			data := map[string]interface{}{"Created": "2015-11-04T10:00:00Z"}
+
+			Expect(R(s).MustStruct().FromMapWith(data, nil)).To(HaveOccurred())
+
+			opts := &MapOptions{ConvertOptions: ConvertOptions{Mode: ConvertLenient}}
+			Expect(R(s).MustStruct().FromMapWith(data, opts)).ToNot(HaveOccurred())
+			Expect(s.Created.Year()).To(Equal(2015))
+		})
+	})
+
+	Describe("Set with options", func() {
+		It("Should convert only when enabled", func() {
+			v := 0
+			r := R(&v).Elem()
+			Expect(r.SetWith(R("5"), nil)).To(HaveOccurred())
+			Expect(r.SetWith(R("5"), &ConvertOptions{Mode: ConvertLenient})).ToNot(HaveOccurred())
+			Expect(v).To(Equal(5))
+		})
+
+		It("Should set fields and map keys", func() {
+			s := &optionsStruct{}
+			opts := &ConvertOptions{Mode: ConvertLenient}
+			Expect(R(s).MustStruct().SetFieldWith("HTTPServerID", 10, opts)).ToNot(HaveOccurred())
+			Expect(s.HTTPServerID).To(Equal("10"))
+
+			m := map[string]int{}
+			Expect(R(m).SetMapKeyWith(R("a"), R("3"), opts)).ToNot(HaveOccurred())
+			Expect(m["a"]).To(Equal(3))
+		})
+
+		It("Should format time when converting to string", func() {
+			t := time.Date(2015, 11, 4, 0, 0, 0, 0, time.UTC)
+			v, err := R(t).ConvertToTypeWith(reflect.TypeOf(""), &ConvertOptions{TimeFormat: "2006-01-02"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v).To(Equal("2015-11-04"))
+		})
+	})
+})
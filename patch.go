@@ -0,0 +1,676 @@
+package reflector
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// PatchOp is a JSON Patch (RFC 6902) operation.
+// Op is one of "add", "remove", "replace", "move", "copy" and "test".
+// Path and From are JSON Pointers (RFC 6901), like "/items/0/name".
+type PatchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// parsePointer splits a JSON Pointer into its unescaped reference tokens.
+// The empty pointer has no tokens and refers to the root value.
+func parsePointer(pointer string) ([]string, error) {
+	tokens := make([]string, 0)
+	if pointer == "" {
+		return tokens, nil
+	}
+	if pointer[0] != '/' {
+		return nil, &Error{
+			Code:    ERR_INVALID_PATH,
+			Message: "JSON pointers must start with /: " + pointer,
+		}
+	}
+
+	for _, token := range strings.Split(pointer[1:], "/") {
+		for i := 0; i < len(token); i++ {
+			if token[i] == '~' && (i+1 == len(token) || (token[i+1] != '0' && token[i+1] != '1')) {
+				return nil, &Error{
+					Code:    ERR_INVALID_PATH,
+					Message: "invalid escape sequence: " + pointer,
+				}
+			}
+		}
+		tokens = append(tokens, pointerUnescaper.Replace(token))
+	}
+	return tokens, nil
+}
+
+// patchValue returns a deep copy of value, so patches never alias the
+// patched value.
+func patchValue(value interface{}) *Reflector {
+	r := Reflect(value)
+	if r == nil || !r.IsValid() {
+		return nil
+	}
+	return r.DeepCopy()
+}
+
+type patcher struct {
+	opts *MapOptions
+	// undo holds functions that revert the changes made so far, in the
+	// order they were made.
+	undo []func()
+}
+
+// set assigns value to target, and records how to revert it.
+func (p *patcher) set(target, value reflect.Value) {
+	old := reflect.New(target.Type()).Elem()
+	old.Set(target)
+	p.undo = append(p.undo, func() { target.Set(old) })
+	target.Set(value)
+}
+
+// setMapIndex stores value in m, or deletes the key if value is the zero
+// Value, and records how to revert it.
+func (p *patcher) setMapIndex(m, key, value reflect.Value) {
+	old := m.MapIndex(key)
+	p.undo = append(p.undo, func() { m.SetMapIndex(key, old) })
+	m.SetMapIndex(key, value)
+}
+
+// rollback reverts all recorded changes, latest first.
+func (p *patcher) rollback() {
+	for i := len(p.undo) - 1; i >= 0; i-- {
+		p.undo[i]()
+	}
+	p.undo = nil
+}
+
+// field returns the struct field that is mapped to name, searching inlined
+// structs as well.
+// Nil inlined struct pointers are allocated if create is true.
+func (p *patcher) field(v reflect.Value, name string, create bool) (reflect.Value, bool) {
+	s, err := newStructReflector(&Reflector{value: v})
+	if err != nil {
+		return reflect.Value{}, false
+	}
+
+	fields := s.mapFields(p.opts)
+	for _, field := range fields {
+		if !field.inline && field.name == name {
+			return field.value.Value(), true
+		}
+	}
+
+	for _, field := range fields {
+		if !field.inline {
+			continue
+		}
+		inline := field.value.Value()
+		if inline.Kind() == reflect.Ptr {
+			if inline.IsNil() {
+				if !create || !inline.CanSet() {
+					continue
+				}
+				if _, ok := p.field(reflect.New(inline.Type().Elem()).Elem(), name, false); !ok {
+					continue
+				}
+				p.set(inline, reflect.New(inline.Type().Elem()))
+			}
+			inline = inline.Elem()
+		}
+		if found, ok := p.field(inline, name, create); ok {
+			return found, true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// child returns the child of the container v identified by token.
+func (p *patcher) child(v reflect.Value, token string) (reflect.Value, error) {
+	if v.Kind() != reflect.Struct {
+		return pathChild(v, token)
+	}
+	field, ok := p.field(v, token, false)
+	if !ok {
+		return reflect.Value{}, newError(ERR_UNKNOWN_FIELD)
+	}
+	return field, nil
+}
+
+// at calls f with the container holding the location identified by tokens,
+// and the last token.
+// Containers that are not addressable, like map values, are modified on a
+// copy that is stored again afterwards.
+func (p *patcher) at(v reflect.Value, tokens []string, f func(container reflect.Value, token string) error) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return newError(ERR_NIL_POINTER)
+		}
+		return p.at(v.Elem(), tokens, f)
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return newError(ERR_INVALID_VALUE)
+		}
+		elem := v.Elem()
+		if elem.Kind() == reflect.Map || elem.Kind() == reflect.Ptr {
+			return p.at(elem, tokens, f)
+		}
+		if !v.CanSet() {
+			return &Error{Code: ERR_UNSETTABLE_VALUE, Target: elem.Type()}
+		}
+		cp := reflect.New(elem.Type()).Elem()
+		cp.Set(elem)
+		if err := p.at(cp, tokens, f); err != nil {
+			return err
+		}
+		p.set(v, cp)
+		return nil
+	}
+
+	if len(tokens) == 1 {
+		return f(v, tokens[0])
+	}
+
+	child, err := p.child(v, tokens[0])
+	if err != nil {
+		return err
+	}
+	if v.Kind() != reflect.Map {
+		return p.at(child, tokens[1:], f)
+	}
+
+	item := reflect.New(v.Type().Elem()).Elem()
+	item.Set(child)
+	if err := p.at(item, tokens[1:], f); err != nil {
+		return err
+	}
+	key, err := mapPathKey(v.Type(), tokens[0])
+	if err != nil {
+		return err
+	}
+	p.setMapIndex(v, key, item)
+	return nil
+}
+
+// root returns the value that the empty pointer refers to.
+func root(v reflect.Value) reflect.Value {
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		return v.Elem()
+	}
+	return v
+}
+
+// assign replaces target with value, decoding maps and slices into
+// structs and typed containers.
+func (p *patcher) assign(target reflect.Value, value *Reflector) error {
+	if !target.CanSet() {
+		return &Error{Code: ERR_UNSETTABLE_VALUE, Target: target.Type()}
+	}
+	// Decode into a new value, so target only changes on success.
+	decoded := reflect.New(target.Type()).Elem()
+	if err := decodeValue(decoded, value, p.opts, 0); err != nil {
+		return err
+	}
+	p.set(target, decoded)
+	return nil
+}
+
+func (p *patcher) get(v reflect.Value, tokens []string) (reflect.Value, error) {
+	if len(tokens) == 0 {
+		return root(v), nil
+	}
+	var value reflect.Value
+	err := p.at(v, tokens, func(container reflect.Value, token string) error {
+		child, err := p.child(container, token)
+		value = child
+		return err
+	})
+	return value, err
+}
+
+func (p *patcher) add(v reflect.Value, tokens []string, value *Reflector) error {
+	if len(tokens) == 0 {
+		return p.assign(root(v), value)
+	}
+
+	return p.at(v, tokens, func(container reflect.Value, token string) error {
+		switch container.Kind() {
+		case reflect.Struct:
+			field, ok := p.field(container, token, true)
+			if !ok {
+				return newError(ERR_UNKNOWN_FIELD)
+			}
+			return p.assign(field, value)
+
+		case reflect.Map:
+			if container.IsNil() {
+				if !container.CanSet() {
+					return newError(ERR_NIL_MAP)
+				}
+				p.set(container, reflect.MakeMap(container.Type()))
+			}
+			key, err := mapPathKey(container.Type(), token)
+			if err != nil {
+				return err
+			}
+			item := reflect.New(container.Type().Elem()).Elem()
+			if err := p.assign(item, value); err != nil {
+				return err
+			}
+			p.setMapIndex(container, key, item)
+			return nil
+
+		case reflect.Slice:
+			index := container.Len()
+			if token != "-" {
+				var err error
+				if index, err = parseIndex(token); err != nil {
+					return err
+				}
+			}
+			if index > container.Len() {
+				return newError(ERR_INDEX_OUT_OF_BOUNDS)
+			}
+			if !container.CanSet() {
+				return &Error{Code: ERR_UNSETTABLE_VALUE, Target: container.Type()}
+			}
+			item := reflect.New(container.Type().Elem()).Elem()
+			if err := p.assign(item, value); err != nil {
+				return err
+			}
+			// Build a new slice, the backing array may be shared.
+			grown := reflect.MakeSlice(container.Type(), container.Len()+1, container.Len()+1)
+			reflect.Copy(grown, container.Slice(0, index))
+			grown.Index(index).Set(item)
+			reflect.Copy(grown.Slice(index+1, grown.Len()), container.Slice(index, container.Len()))
+			p.set(container, grown)
+			return nil
+		}
+
+		return &Error{
+			Code:    ERR_INVALID_PATH,
+			Message: "can't add to " + container.Kind().String(),
+		}
+	})
+}
+
+// remove removes the value at tokens and returns it.
+// Struct fields can't be removed, so they are set to their zero value.
+func (p *patcher) remove(v reflect.Value, tokens []string) (*Reflector, error) {
+	if len(tokens) == 0 {
+		return nil, &Error{Code: ERR_INVALID_PATH, Message: "can't remove the root value"}
+	}
+
+	var removed *Reflector
+	err := p.at(v, tokens, func(container reflect.Value, token string) error {
+		child, err := p.child(container, token)
+		if err != nil {
+			return err
+		}
+		// Keep a copy, the original is overwritten below.
+		old := reflect.New(child.Type()).Elem()
+		old.Set(child)
+		removed = Reflect(old)
+
+		switch container.Kind() {
+		case reflect.Struct:
+			return p.assign(child, nil)
+
+		case reflect.Map:
+			key, err := mapPathKey(container.Type(), token)
+			if err != nil {
+				return err
+			}
+			p.setMapIndex(container, key, reflect.Value{})
+			return nil
+
+		case reflect.Slice:
+			if !container.CanSet() {
+				return &Error{Code: ERR_UNSETTABLE_VALUE, Target: container.Type()}
+			}
+			// Build a new slice, the backing array may be shared.
+			index, _ := parseIndex(token)
+			shrunk := reflect.MakeSlice(container.Type(), container.Len()-1, container.Len()-1)
+			reflect.Copy(shrunk, container.Slice(0, index))
+			reflect.Copy(shrunk.Slice(index, shrunk.Len()), container.Slice(index+1, container.Len()))
+			p.set(container, shrunk)
+			return nil
+		}
+
+		return &Error{
+			Code:    ERR_INVALID_PATH,
+			Message: "can't remove from " + container.Kind().String(),
+		}
+	})
+	return removed, err
+}
+
+func (p *patcher) replace(v reflect.Value, tokens []string, value *Reflector) error {
+	if len(tokens) == 0 {
+		return p.assign(root(v), value)
+	}
+
+	return p.at(v, tokens, func(container reflect.Value, token string) error {
+		child, err := p.child(container, token)
+		if err != nil {
+			return err
+		}
+		if container.Kind() != reflect.Map {
+			return p.assign(child, value)
+		}
+
+		key, err := mapPathKey(container.Type(), token)
+		if err != nil {
+			return err
+		}
+		item := reflect.New(container.Type().Elem()).Elem()
+		if err := p.assign(item, value); err != nil {
+			return err
+		}
+		p.setMapIndex(container, key, item)
+		return nil
+	})
+}
+
+// test compares the value at tokens with value.
+// Both are converted to generic trees first, so numbers of different types
+// and structs and maps with the same content are equal.
+func (p *patcher) test(v reflect.Value, tokens []string, value interface{}) error {
+	actual, err := p.get(v, tokens)
+	if err != nil {
+		return err
+	}
+
+	// Values are compared like JSON values, so "1" and 1 differ, but
+	// numbers of different types are equal.
+	a, err := p.jsonValue(actual)
+	if err != nil {
+		return err
+	}
+	b, err := p.jsonValue(reflect.ValueOf(value))
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(a, b) {
+		return &Error{
+			Code:    ERR_PATCH_TEST_FAILED,
+			Message: fmt.Sprintf("expected %v, got %v", b, a),
+		}
+	}
+	return nil
+}
+
+// jsonValue encodes v like ToMapWith and returns it as decoded JSON, with
+// float64 numbers.
+func (p *patcher) jsonValue(v reflect.Value) (interface{}, error) {
+	data, err := json.Marshal(newEncoder(p.opts).encode(v, 1))
+	if err != nil {
+		return nil, &Error{Code: ERR_INVALID_VALUE, Err: err}
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, &Error{Code: ERR_INVALID_VALUE, Err: err}
+	}
+	return value, nil
+}
+
+func (p *patcher) apply(v reflect.Value, op *PatchOp) error {
+	tokens, err := parsePointer(op.Path)
+	if err != nil {
+		return err
+	}
+
+	var from []string
+	if op.Op == "move" || op.Op == "copy" {
+		if from, err = parsePointer(op.From); err != nil {
+			return err
+		}
+	}
+
+	switch op.Op {
+	case "add":
+		err = p.add(v, tokens, patchValue(op.Value))
+	case "remove":
+		_, err = p.remove(v, tokens)
+	case "replace":
+		err = p.replace(v, tokens, patchValue(op.Value))
+	case "test":
+		err = p.test(v, tokens, op.Value)
+
+	case "move":
+		if op.From == op.Path {
+			return nil
+		}
+		if strings.HasPrefix(op.Path, op.From+"/") {
+			return &Error{
+				Code:    ERR_INVALID_PATH,
+				Path:    op.Path,
+				Message: "can't move a value into one of its children",
+			}
+		}
+		var removed *Reflector
+		if removed, err = p.remove(v, from); err != nil {
+			return prefixPath(err, op.From)
+		}
+		err = p.add(v, tokens, removed)
+
+	case "copy":
+		var value reflect.Value
+		if value, err = p.get(v, from); err != nil {
+			return prefixPath(err, op.From)
+		}
+		err = p.add(v, tokens, patchValue(value))
+
+	default:
+		return &Error{Code: ERR_UNKNOWN_OPERATOR, Message: op.Op}
+	}
+
+	return prefixPath(err, op.Path)
+}
+
+// mergeable returns true if an object in a merge patch is merged into
+// values of type typ instead of replacing them.
+func mergeable(typ reflect.Type) bool {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Map, reflect.Interface:
+		return true
+	case reflect.Struct:
+		return typ != timeType && !isMarshaler(typ)
+	}
+	return false
+}
+
+// merge applies a JSON Merge Patch (RFC 7386) object to v.
+func (p *patcher) merge(v reflect.Value, patch map[string]interface{}) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			if !v.CanSet() {
+				return newError(ERR_NIL_POINTER)
+			}
+			p.set(v, reflect.New(v.Type().Elem()))
+		}
+		return p.merge(v.Elem(), patch)
+
+	case reflect.Interface:
+		if !v.IsNil() {
+			elem := v.Elem()
+			if elem.Kind() == reflect.Map || elem.Kind() == reflect.Ptr {
+				return p.merge(elem, patch)
+			}
+			if elem.Kind() == reflect.Struct {
+				if !v.CanSet() {
+					return &Error{Code: ERR_UNSETTABLE_VALUE, Target: elem.Type()}
+				}
+				cp := reflect.New(elem.Type()).Elem()
+				cp.Set(elem)
+				if err := p.merge(cp, patch); err != nil {
+					return err
+				}
+				p.set(v, cp)
+				return nil
+			}
+		}
+
+		// Patching a non-object replaces it with an object.
+		m := reflect.ValueOf(make(map[string]interface{}))
+		if !m.Type().AssignableTo(v.Type()) {
+			return &Error{Code: ERR_TYPE_MISMATCH, Source: m.Type(), Target: v.Type()}
+		}
+		if !v.CanSet() {
+			return &Error{Code: ERR_UNSETTABLE_VALUE, Target: v.Type()}
+		}
+		if err := p.merge(m, patch); err != nil {
+			return err
+		}
+		p.set(v, m)
+		return nil
+
+	case reflect.Struct:
+		if !mergeable(v.Type()) {
+			break
+		}
+		keys := make([]string, 0, len(patch))
+		for key := range patch {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			field, ok := p.field(v, key, patch[key] != nil)
+			if !ok {
+				if p.opts.ErrorOnUnknown || p.opts.Strict {
+					return &Error{Code: ERR_UNKNOWN_FIELD, Path: key}
+				}
+				continue
+			}
+			if err := p.mergeValue(field, patch[key]); err != nil {
+				return prefixPath(err, key)
+			}
+		}
+		return nil
+
+	case reflect.Map:
+		if v.IsNil() {
+			if !v.CanSet() {
+				return newError(ERR_NIL_MAP)
+			}
+			p.set(v, reflect.MakeMap(v.Type()))
+		}
+		for key, value := range patch {
+			mapKey, err := mapPathKey(v.Type(), key)
+			if err != nil {
+				return prefixPath(err, key)
+			}
+			if value == nil {
+				p.setMapIndex(v, mapKey, reflect.Value{})
+				continue
+			}
+
+			item := reflect.New(v.Type().Elem()).Elem()
+			if existing := v.MapIndex(mapKey); existing.IsValid() {
+				item.Set(existing)
+			}
+			if err := p.mergeValue(item, value); err != nil {
+				return prefixPath(err, key)
+			}
+			p.setMapIndex(v, mapKey, item)
+		}
+		return nil
+	}
+
+	return p.assign(v, patchValue(patch))
+}
+
+// mergeValue merges value into target. Null values reset the target to
+// its zero value, objects are merged recursively and all other values
+// replace the target.
+func (p *patcher) mergeValue(target reflect.Value, value interface{}) error {
+	if m, ok := value.(map[string]interface{}); ok && mergeable(target.Type()) {
+		return p.merge(target, m)
+	}
+	return p.assign(target, patchValue(value))
+}
+
+// patch calls apply with the value, and reverts all changes if that fails,
+// so the value is left untouched on errors.
+func (r *Reflector) patch(p *patcher, apply func(v reflect.Value) error) error {
+	if !r.IsValid() || !r.value.CanInterface() {
+		return newError(ERR_INVALID_VALUE)
+	}
+	p.undo = nil
+	if err := apply(r.value); err != nil {
+		p.rollback()
+		return err
+	}
+	p.undo = nil
+	return nil
+}
+
+// ApplyMergePatch applies a JSON Merge Patch (RFC 7386) to the value.
+// Objects are merged recursively into structs and maps, null values delete
+// map keys and reset struct fields to their zero value, and all other values
+// replace the current value.
+// Struct fields are matched by their name, or by the tag configured with
+// ApplyMergePatchWith, and unknown keys are ignored.
+// The value should be a pointer. It is patched in place, so untouched
+// pointers, slices and maps are kept, and left unchanged if the patch fails.
+// If convert is true, values are converted to the field types.
+func (r *Reflector) ApplyMergePatch(patch map[string]interface{}, convert ...bool) error {
+	return r.ApplyMergePatchWith(patch, &MapOptions{
+		ConvertOptions: ConvertOptions{Mode: convertMode(convert)},
+	})
+}
+
+// ApplyMergePatchWith applies a JSON Merge Patch (RFC 7386), using the
+// given options. See ApplyMergePatch.
+func (r *Reflector) ApplyMergePatchWith(patch map[string]interface{}, opts *MapOptions) error {
+	if opts == nil {
+		opts = &MapOptions{}
+	}
+	p := &patcher{opts: opts}
+	return r.patch(p, func(v reflect.Value) error {
+		return p.merge(v, patch)
+	})
+}
+
+// ApplyPatch applies JSON Patch (RFC 6902) operations to the value.
+// Paths are JSON Pointers into structs, maps, slices and arrays.
+// Struct fields are matched by their name, or by the tag configured with
+// ApplyPatchWith. Struct fields can't be removed, so "remove" sets them to
+// their zero value. "test" compares values like JSON values, so the string
+// "1" does not equal the number 1.
+// The value should be a pointer. Operations are applied all or nothing: the
+// value is left unchanged if any of them fails.
+// If convert is true, values are converted to the target types.
+func (r *Reflector) ApplyPatch(ops []PatchOp, convert ...bool) error {
+	return r.ApplyPatchWith(ops, &MapOptions{
+		ConvertOptions: ConvertOptions{Mode: convertMode(convert)},
+	})
+}
+
+// ApplyPatchWith applies JSON Patch (RFC 6902) operations, using the given
+// options. See ApplyPatch.
+func (r *Reflector) ApplyPatchWith(ops []PatchOp, opts *MapOptions) error {
+	if opts == nil {
+		opts = &MapOptions{}
+	}
+	p := &patcher{opts: opts}
+	return r.patch(p, func(v reflect.Value) error {
+		for i := range ops {
+			if err := p.apply(v, &ops[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
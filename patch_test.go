@@ -0,0 +1,244 @@
+package reflector_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+
+	. "github.com/theduke/go-reflector"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type patchAddress struct {
+	Street string `json:"street"`
+	City   string `json:"city"`
+}
+
+type patchUser struct {
+	Name    string                 `json:"name"`
+	Age     int                    `json:"age"`
+	Tags    []string               `json:"tags"`
+	Address *patchAddress          `json:"address"`
+	Meta    map[string]interface{} `json:"meta"`
+	Scores  map[string]int         `json:"scores"`
+}
+
+type patchService struct {
+	Name  string
+	cache map[string]int
+}
+
+var _ = Describe("Patches", func() {
+	jsonOpts := &MapOptions{Tag: "json", ConvertOptions: ConvertOptions{Mode: ConvertLenient}}
+	var u *patchUser
+
+	BeforeEach(func() {
+		u = &patchUser{
+			Name:    "Jane",
+			Age:     30,
+			Tags:    []string{"a", "b"},
+			Address: &patchAddress{Street: "Main", City: "Vienna"},
+			Meta:    map[string]interface{}{"x": 1.0, "nested": map[string]interface{}{"y": "z"}},
+			Scores:  map[string]int{"math": 1},
+		}
+	})
+
+	Describe("ApplyMergePatch", func() {
+		It("Should merge objects recursively", func() {
+			err := R(u).ApplyMergePatchWith(map[string]interface{}{
+				"name":    "John",
+				"address": map[string]interface{}{"city": "Graz"},
+				"meta":    map[string]interface{}{"nested": map[string]interface{}{"w": true, "y": nil}},
+				"scores":  map[string]interface{}{"art": 2.0},
+				"unknown": 1,
+			}, jsonOpts)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(u.Name).To(Equal("John"))
+			Expect(u.Age).To(Equal(30))
+			Expect(u.Address).To(Equal(&patchAddress{Street: "Main", City: "Graz"}))
+			Expect(u.Meta).To(Equal(map[string]interface{}{"x": 1.0, "nested": map[string]interface{}{"w": true}}))
+			Expect(u.Scores).To(Equal(map[string]int{"math": 1, "art": 2}))
+		})
+
+		It("Should delete and reset values with null", func() {
+			err := R(u).ApplyMergePatch(map[string]interface{}{
+				"Age":     nil,
+				"Address": nil,
+				"Meta":    map[string]interface{}{"x": nil},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(u.Age).To(Equal(0))
+			Expect(u.Address).To(BeNil())
+			Expect(u.Meta).ToNot(HaveKey("x"))
+		})
+
+		It("Should replace slices and allocate nil pointers", func() {
+			u.Address = nil
+			err := R(u).ApplyMergePatch(map[string]interface{}{
+				"Tags":    []interface{}{"c"},
+				"Address": map[string]interface{}{"City": "Linz"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(u.Tags).To(Equal([]string{"c"}))
+			Expect(u.Address).To(Equal(&patchAddress{City: "Linz"}))
+		})
+
+		It("Should patch maps", func() {
+			m := map[string]interface{}{"a": 1, "b": map[string]interface{}{"c": 2}}
+			Expect(R(m).ApplyMergePatch(map[string]interface{}{
+				"a": nil,
+				"b": map[string]interface{}{"d": 3},
+			})).To(Succeed())
+			Expect(m).To(Equal(map[string]interface{}{"b": map[string]interface{}{"c": 2, "d": 3}}))
+		})
+
+		It("Should leave the value unchanged on errors", func() {
+			err := R(u).ApplyMergePatch(map[string]interface{}{
+				"Name":    "John",
+				"Address": map[string]interface{}{"City": "Graz"},
+				"Scores":  map[string]interface{}{"art": "x"},
+			})
+			Expect(errors.Is(err, ErrTypeMismatch)).To(BeTrue())
+			Expect(err.(*Error).Path).To(Equal("Scores.art"))
+			Expect(u.Name).To(Equal("Jane"))
+			Expect(u.Address.City).To(Equal("Vienna"))
+		})
+
+		It("Should report unknown fields if configured", func() {
+			err := R(u).ApplyMergePatchWith(map[string]interface{}{"nope": 1}, &MapOptions{ErrorOnUnknown: true})
+			Expect(errors.Is(err, ErrUnknownField)).To(BeTrue())
+		})
+	})
+
+	Describe("ApplyPatch", func() {
+		It("Should apply operations", func() {
+			var ops []PatchOp
+			Expect(json.Unmarshal([]byte(`[
+				{"op": "test", "path": "/name", "value": "Jane"},
+				{"op": "replace", "path": "/name", "value": "John"},
+				{"op": "add", "path": "/tags/1", "value": "x"},
+				{"op": "add", "path": "/tags/-", "value": "z"},
+				{"op": "remove", "path": "/tags/0"},
+				{"op": "add", "path": "/meta/nested/a~1b", "value": [1, 2]},
+				{"op": "copy", "from": "/address/city", "path": "/meta/city"},
+				{"op": "move", "from": "/scores/math", "path": "/scores/physics"},
+				{"op": "remove", "path": "/age"},
+				{"op": "test", "path": "/address", "value": {"street": "Main", "city": "Vienna"}}
+			]`), &ops)).To(Succeed())
+
+			Expect(R(u).ApplyPatchWith(ops, jsonOpts)).To(Succeed())
+			Expect(u).To(Equal(&patchUser{
+				Name:    "John",
+				Tags:    []string{"x", "b", "z"},
+				Address: &patchAddress{Street: "Main", City: "Vienna"},
+				Meta: map[string]interface{}{
+					"x":      1.0,
+					"city":   "Vienna",
+					"nested": map[string]interface{}{"y": "z", "a/b": []interface{}{1.0, 2.0}},
+				},
+				Scores: map[string]int{"physics": 1},
+			}))
+		})
+
+		It("Should decode values into typed fields", func() {
+			Expect(R(u).ApplyPatch([]PatchOp{
+				{Op: "replace", Path: "/Address", Value: map[string]interface{}{"City": "Graz"}},
+				{Op: "add", Path: "/Scores/art", Value: "3"},
+			}, true)).To(Succeed())
+			Expect(u.Address).To(Equal(&patchAddress{City: "Graz"}))
+			Expect(u.Scores["art"]).To(Equal(3))
+		})
+
+		It("Should roll back all operations on errors", func() {
+			err := R(u).ApplyPatch([]PatchOp{
+				{Op: "replace", Path: "/Name", Value: "John"},
+				{Op: "remove", Path: "/Tags/0"},
+				{Op: "add", Path: "/Meta/new", Value: 1},
+				{Op: "test", Path: "/Age", Value: 31},
+			})
+			Expect(errors.Is(err, ErrPatchTestFailed)).To(BeTrue())
+			Expect(err.(*Error).Path).To(Equal("/Age"))
+
+			Expect(u.Name).To(Equal("Jane"))
+			Expect(u.Tags).To(Equal([]string{"a", "b"}))
+			Expect(u.Meta).ToNot(HaveKey("new"))
+		})
+
+		It("Should test values with JSON equality", func() {
+			Expect(R(u).ApplyPatch([]PatchOp{
+				{Op: "test", Path: "/Age", Value: 30.0},
+				{Op: "test", Path: "/Tags", Value: []interface{}{"a", "b"}},
+				{Op: "test", Path: "/Scores", Value: map[string]interface{}{"math": 1}},
+			})).To(Succeed())
+
+			err := R(u).ApplyPatch([]PatchOp{{Op: "test", Path: "/Age", Value: "30"}})
+			Expect(errors.Is(err, ErrPatchTestFailed)).To(BeTrue())
+
+			err = R(u).ApplyPatch([]PatchOp{{Op: "test", Path: "/Meta/x", Value: true}})
+			Expect(errors.Is(err, ErrPatchTestFailed)).To(BeTrue())
+		})
+
+		It("Should leave unexported fields untouched", func() {
+			s := &patchService{Name: "a", cache: map[string]int{"x": 1}}
+			cache := s.cache
+
+			Expect(R(s).ApplyPatch([]PatchOp{{Op: "replace", Path: "/Name", Value: "b"}})).To(Succeed())
+			Expect(s.Name).To(Equal("b"))
+			cache["y"] = 2
+			Expect(s.cache).To(HaveKey("y"))
+		})
+
+		It("Should keep untouched pointers, slices and maps", func() {
+			address, tags, scores := u.Address, u.Tags, u.Scores
+
+			Expect(R(u).ApplyMergePatch(map[string]interface{}{"Name": "b"})).To(Succeed())
+			Expect(R(u).ApplyPatch([]PatchOp{
+				{Op: "replace", Path: "/Name", Value: "c"},
+				{Op: "replace", Path: "/Address/City", Value: "Graz"},
+			})).To(Succeed())
+
+			Expect(u.Name).To(Equal("c"))
+			Expect(u.Address).To(BeIdenticalTo(address))
+			Expect(address.City).To(Equal("Graz"))
+			Expect(&u.Tags[0]).To(BeIdenticalTo(&tags[0]))
+			Expect(reflect.ValueOf(u.Scores).Pointer()).To(Equal(reflect.ValueOf(scores).Pointer()))
+		})
+
+		It("Should not write into shared backing arrays", func() {
+			backing := []string{"a", "b", "c"}
+			u.Tags = backing[:2]
+
+			Expect(R(u).ApplyPatch([]PatchOp{
+				{Op: "add", Path: "/Tags/0", Value: "x"},
+				{Op: "remove", Path: "/Tags/1"},
+			})).To(Succeed())
+			Expect(u.Tags).To(Equal([]string{"x", "b"}))
+			Expect(backing).To(Equal([]string{"a", "b", "c"}))
+		})
+
+		It("Should report invalid operations", func() {
+			expectError := func(op PatchOp, sentinel error) {
+				err := R(u).ApplyPatch([]PatchOp{op})
+				Expect(errors.Is(err, sentinel)).To(BeTrue(), "%v: %v", op, err)
+			}
+
+			expectError(PatchOp{Op: "nope", Path: "/Name"}, ErrUnknownOperator)
+			expectError(PatchOp{Op: "remove", Path: "Name"}, ErrInvalidPath)
+			expectError(PatchOp{Op: "remove", Path: "/Nope"}, ErrUnknownField)
+			expectError(PatchOp{Op: "remove", Path: "/Meta/nope"}, ErrUnknownKey)
+			expectError(PatchOp{Op: "replace", Path: "/Tags/2", Value: "c"}, ErrIndexOutOfBounds)
+			expectError(PatchOp{Op: "add", Path: "/Tags/3", Value: "c"}, ErrIndexOutOfBounds)
+			expectError(PatchOp{Op: "move", From: "/Address", Path: "/Address/City"}, ErrInvalidPath)
+		})
+
+		It("Should replace the root value", func() {
+			Expect(R(u).ApplyPatch([]PatchOp{
+				{Op: "replace", Path: "", Value: map[string]interface{}{"Name": "Root"}},
+			})).To(Succeed())
+			Expect(u).To(Equal(&patchUser{Name: "Root"}))
+		})
+	})
+})
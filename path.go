@@ -0,0 +1,391 @@
+package reflector
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// parsePath splits a path like `Address.Lines[2].Street` or `Tags["a.b"]`
+// into its segments.
+// An empty path has no segments and refers to the root value.
+func parsePath(path string) ([]string, error) {
+	segments := make([]string, 0)
+	if path == "" {
+		return segments, nil
+	}
+
+	invalid := func(msg string) error {
+		return &Error{
+			Code:    ERR_INVALID_PATH,
+			Message: msg + ": " + path,
+		}
+	}
+
+	current := ""
+	// afterBracket is true right after a closing bracket, where only
+	// a dot or another bracket may follow.
+	afterBracket := false
+
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		switch c {
+		case '.':
+			if current == "" && !afterBracket {
+				return nil, invalid("empty path segment")
+			}
+			if current != "" {
+				segments = append(segments, current)
+			}
+			current = ""
+			afterBracket = false
+
+			if i == len(path)-1 {
+				return nil, invalid("path may not end with a dot")
+			}
+		case '[':
+			if current != "" {
+				segments = append(segments, current)
+				current = ""
+			}
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, invalid("unterminated bracket")
+			}
+			segment := path[i+1 : i+end]
+			if len(segment) >= 2 && (segment[0] == '"' || segment[0] == '\'') && segment[len(segment)-1] == segment[0] {
+				segment = segment[1 : len(segment)-1]
+			} else if segment == "" {
+				return nil, invalid("empty brackets")
+			}
+			segments = append(segments, segment)
+			i += end
+			afterBracket = true
+		default:
+			if afterBracket {
+				return nil, invalid("expected . or [ after ]")
+			}
+			current += string(c)
+		}
+	}
+	if current != "" {
+		segments = append(segments, current)
+	}
+
+	return segments, nil
+}
+
+// segmentPath returns the path fragment for segment, depending on the kind
+// of the container it is used on.
+func segmentPath(kind reflect.Kind, segment string) string {
+	if kind == reflect.Slice || kind == reflect.Array {
+		return "[" + segment + "]"
+	}
+	return segment
+}
+
+func parseIndex(segment string) (int, error) {
+	index, err := strconv.Atoi(segment)
+	if err != nil || index < 0 {
+		return 0, &Error{
+			Code:    ERR_INVALID_PATH,
+			Message: "invalid index " + segment,
+			Err:     err,
+		}
+	}
+	return index, nil
+}
+
+// mapPathKey converts a path segment into a key for the given map type.
+func mapPathKey(typ reflect.Type, segment string) (reflect.Value, error) {
+	key, err := Reflect(segment).ConvertToType(typ.Key())
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return reflect.ValueOf(key).Convert(typ.Key()), nil
+}
+
+// fieldByName returns the field of the struct v that is named segment.
+// Fields promoted through nil embedded pointers are ERR_NIL_POINTER errors,
+// unless alloc is true and the pointer can be set.
+func fieldByName(v reflect.Value, segment string, alloc bool) (reflect.Value, error) {
+	info, ok := v.Type().FieldByName(segment)
+	if !ok {
+		return reflect.Value{}, newError(ERR_UNKNOWN_FIELD)
+	}
+	// Unlike reflect.Value.FieldByName, don't panic on nil pointers.
+	for i, index := range info.Index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc || !v.CanSet() {
+					return reflect.Value{}, newError(ERR_NIL_POINTER)
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(index)
+	}
+	return v, nil
+}
+
+// pathChild returns the child of v that is identified by segment.
+// Pointers and interfaces must already be de-referenced.
+func pathChild(v reflect.Value, segment string) (reflect.Value, error) {
+	switch v.Kind() {
+	case reflect.Struct:
+		return fieldByName(v, segment, false)
+
+	case reflect.Map:
+		key, err := mapPathKey(v.Type(), segment)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		val := v.MapIndex(key)
+		if !val.IsValid() {
+			return reflect.Value{}, newError(ERR_UNKNOWN_KEY)
+		}
+		return val, nil
+
+	case reflect.Slice, reflect.Array:
+		index, err := parseIndex(segment)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if index >= v.Len() {
+			return reflect.Value{}, newError(ERR_INDEX_OUT_OF_BOUNDS)
+		}
+		return v.Index(index), nil
+	}
+
+	return reflect.Value{}, &Error{
+		Code:    ERR_INVALID_PATH,
+		Message: "can't descend into " + v.Kind().String(),
+	}
+}
+
+// getPath follows segments starting at v.
+func getPath(v reflect.Value, segments []string) (reflect.Value, error) {
+	path := ""
+	for _, segment := range segments {
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				code := ERR_NIL_POINTER
+				if v.Kind() == reflect.Interface {
+					code = ERR_INVALID_VALUE
+				}
+				return reflect.Value{}, &Error{Code: code, Path: path}
+			}
+			v = v.Elem()
+		}
+
+		path = joinPath(path, segmentPath(v.Kind(), segment))
+		child, err := pathChild(v, segment)
+		if err != nil {
+			return reflect.Value{}, prefixPath(err, path)
+		}
+		v = child
+	}
+	return v, nil
+}
+
+// setPath assigns value to the location identified by segments, starting at v.
+// Nil pointers and maps are allocated and slices grown as needed, which
+// requires v to be settable.
+func setPath(v reflect.Value, segments []string, value *Reflector, opts *ConvertOptions) error {
+	if len(segments) == 0 {
+		return assignValue(v, value, opts)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			if !v.CanSet() {
+				return newError(ERR_NIL_POINTER)
+			}
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setPath(v.Elem(), segments, value, opts)
+
+	case reflect.Interface:
+		if v.IsNil() {
+			if !v.CanSet() {
+				return newError(ERR_INVALID_VALUE)
+			}
+			// Nothing to go on, so build a generic map.
+			m := reflect.ValueOf(make(map[string]interface{}))
+			if !m.Type().AssignableTo(v.Type()) {
+				return &Error{Code: ERR_INVALID_VALUE, Target: v.Type()}
+			}
+			v.Set(m)
+		}
+
+		elem := v.Elem()
+		if elem.Kind() == reflect.Map || elem.Kind() == reflect.Ptr {
+			// Reference types can be modified in place.
+			return setPath(elem, segments, value, opts)
+		}
+
+		if !v.CanSet() {
+			return &Error{Code: ERR_UNSETTABLE_VALUE, Target: elem.Type()}
+		}
+		// Values stored in an interface are not addressable, so
+		// modify a copy and store it again.
+		cp := reflect.New(elem.Type()).Elem()
+		cp.Set(elem)
+		if err := setPath(cp, segments, value, opts); err != nil {
+			return err
+		}
+		v.Set(cp)
+		return nil
+	}
+
+	segment := segments[0]
+	fragment := segmentPath(v.Kind(), segment)
+
+	switch v.Kind() {
+	case reflect.Struct:
+		field, err := fieldByName(v, segment, true)
+		if err != nil {
+			return prefixPath(err, fragment)
+		}
+		err = setPath(field, segments[1:], value, opts)
+		return prefixPath(err, fragment)
+
+	case reflect.Map:
+		if v.IsNil() {
+			if !v.CanSet() {
+				return &Error{Code: ERR_NIL_MAP, Path: fragment}
+			}
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+
+		key, err := mapPathKey(v.Type(), segment)
+		if err != nil {
+			return prefixPath(err, fragment)
+		}
+
+		// Map values are not addressable, so modify a copy and store it.
+		item := reflect.New(v.Type().Elem()).Elem()
+		if existing := v.MapIndex(key); existing.IsValid() {
+			item.Set(existing)
+		}
+		if err := setPath(item, segments[1:], value, opts); err != nil {
+			return prefixPath(err, fragment)
+		}
+		v.SetMapIndex(key, item)
+		return nil
+
+	case reflect.Slice:
+		index, err := parseIndex(segment)
+		if err != nil {
+			return prefixPath(err, fragment)
+		}
+		if index >= v.Len() {
+			if !v.CanSet() {
+				return &Error{Code: ERR_INDEX_OUT_OF_BOUNDS, Path: fragment}
+			}
+			grow := reflect.MakeSlice(v.Type(), index+1-v.Len(), index+1-v.Len())
+			v.Set(reflect.AppendSlice(v, grow))
+		}
+		err = setPath(v.Index(index), segments[1:], value, opts)
+		return prefixPath(err, fragment)
+
+	case reflect.Array:
+		index, err := parseIndex(segment)
+		if err != nil {
+			return prefixPath(err, fragment)
+		}
+		if index >= v.Len() {
+			return &Error{Code: ERR_INDEX_OUT_OF_BOUNDS, Path: fragment}
+		}
+		err = setPath(v.Index(index), segments[1:], value, opts)
+		return prefixPath(err, fragment)
+	}
+
+	return &Error{
+		Code:    ERR_INVALID_PATH,
+		Path:    fragment,
+		Message: "can't descend into " + v.Kind().String(),
+	}
+}
+
+// assignValue sets v to value.
+// Unlike Reflector.Set, it accepts values that are assignable to the target
+// type, like any value for interface{} targets, and sets the zero value
+// for invalid values.
+func assignValue(v reflect.Value, value *Reflector, opts *ConvertOptions) error {
+	if !v.CanSet() {
+		return &Error{Code: ERR_UNSETTABLE_VALUE, Target: v.Type()}
+	}
+	if value == nil || !value.IsValid() {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	if value.Type().AssignableTo(v.Type()) {
+		v.Set(value.Value())
+		return nil
+	}
+	return Reflect(v).SetWith(value, opts)
+}
+
+// Get returns a Reflector for the value at path, which may contain struct
+// fields, map keys and slice or array indexes, for example
+// `Address.Lines[2].Street` or `Tags["a.b"]`.
+// Pointers and interfaces are de-referenced automatically.
+func (r *Reflector) Get(path string) (*Reflector, error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	v, err := getPath(r.value, segments)
+	if err != nil {
+		return nil, err
+	}
+	return Reflect(v), nil
+}
+
+// GetValue returns the value at path.
+// See Get for details.
+func (r *Reflector) GetValue(path string) (interface{}, error) {
+	v, err := r.Get(path)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, nil
+	}
+	return v.Interface(), nil
+}
+
+// HasPath returns true if the path can be resolved.
+func (r *Reflector) HasPath(path string) bool {
+	_, err := r.Get(path)
+	return err == nil
+}
+
+// SetPath sets the value at path.
+// See Get for the path syntax.
+// Nil pointers and nil maps along the path are allocated, and slices are grown
+// to fit the index, as long as the root value is addressable (a pointer).
+// If convert is true, the value is converted to the target type.
+func (r *Reflector) SetPath(path string, value interface{}, convert ...bool) error {
+	return r.SetPathWith(path, value, convertOptions(convert))
+}
+
+// SetPathWith sets the value at path, converting it according to the given
+// options if the types differ.
+// See SetPath for details.
+func (r *Reflector) SetPathWith(path string, value interface{}, opts *ConvertOptions) error {
+	segments, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+
+	val, ok := value.(*Reflector)
+	if !ok {
+		val = Reflect(value)
+	}
+
+	return setPath(r.value, segments, val, opts)
+}
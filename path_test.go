@@ -0,0 +1,209 @@
+package reflector_test
+
+import (
+	"errors"
+
+	. "github.com/theduke/go-reflector"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type pathLine struct {
+	Street string
+}
+
+type pathAddress struct {
+	Lines []pathLine
+	Zip   *int
+}
+
+type pathStruct struct {
+	Name    string
+	Address *pathAddress
+	Tags    map[string]string
+	Data    map[string]interface{}
+	Items   []*pathLine
+	Fixed   [2]int
+	Any     interface{}
+}
+
+// PathBase is embedded by pointer, so its fields are promoted.
+type PathBase struct {
+	X int
+}
+
+type pathEmbedding struct {
+	*PathBase
+	*pathLine
+}
+
+var _ = Describe("Path", func() {
+	var s *pathStruct
+
+	BeforeEach(func() {
+		s = &pathStruct{
+			Name: "n",
+			Address: &pathAddress{
+				Lines: []pathLine{{"a"}, {"b"}, {"c"}},
+			},
+			Tags: map[string]string{"a.b": "x"},
+			Data: map[string]interface{}{
+				"nested": map[string]interface{}{
+					"list": []interface{}{1, 2},
+				},
+			},
+			Fixed: [2]int{5, 6},
+			Any:   pathLine{"any"},
+		}
+	})
+
+	Describe("Get", func() {
+		It("Should get struct fields", func() {
+			Expect(R(s).GetValue("Name")).To(Equal("n"))
+		})
+
+		It("Should return root for empty path", func() {
+			v, err := R(s).Get("")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v.Interface()).To(Equal(s))
+		})
+
+		It("Should get through pointers and slices", func() {
+			Expect(R(s).GetValue("Address.Lines[2].Street")).To(Equal("c"))
+		})
+
+		It("Should get map keys", func() {
+			Expect(R(s).GetValue(`Tags["a.b"]`)).To(Equal("x"))
+			Expect(R(s).GetValue("Data.nested.list[1]")).To(Equal(2))
+		})
+
+		It("Should get array indexes", func() {
+			Expect(R(s).GetValue("Fixed[1]")).To(Equal(6))
+		})
+
+		It("Should get through interfaces", func() {
+			Expect(R(s).GetValue("Any.Street")).To(Equal("any"))
+		})
+
+		It("Should report the failing segment", func() {
+			_, err := R(s).Get("Address.Lines[5].Street")
+			Expect(errors.Is(err, ErrIndexOutOfBounds)).To(BeTrue())
+
+			var rErr *Error
+			Expect(errors.As(err, &rErr)).To(BeTrue())
+			Expect(rErr.Path).To(Equal("Address.Lines[5]"))
+		})
+
+		It("Should report nil pointers", func() {
+			s.Address = nil
+			_, err := R(s).Get("Address.Lines")
+			Expect(errors.Is(err, ErrNilPointer)).To(BeTrue())
+		})
+
+		It("Should get promoted fields of embedded pointers", func() {
+			e := &pathEmbedding{PathBase: &PathBase{X: 1}, pathLine: &pathLine{"s"}}
+			Expect(R(e).GetValue("X")).To(Equal(1))
+			Expect(R(e).GetValue("Street")).To(Equal("s"))
+		})
+
+		It("Should report nil embedded pointers", func() {
+			_, err := R(&pathEmbedding{}).Get("X")
+			Expect(errors.Is(err, ErrNilPointer)).To(BeTrue())
+			Expect(err.(*Error).Path).To(Equal("X"))
+		})
+
+		It("Should report unknown fields and keys", func() {
+			_, err := R(s).Get("Address.Nope")
+			Expect(errors.Is(err, ErrUnknownField)).To(BeTrue())
+
+			_, err = R(s).Get("Tags.nope")
+			Expect(errors.Is(err, ErrUnknownKey)).To(BeTrue())
+		})
+
+		It("Should reject invalid paths", func() {
+			for _, path := range []string{"a..b", "a.", "a[1", "a[]", "a[1]b"} {
+				_, err := R(s).Get(path)
+				Expect(errors.Is(err, ErrInvalidPath)).To(BeTrue(), path)
+			}
+		})
+	})
+
+	Describe("HasPath", func() {
+		It("Should check paths", func() {
+			Expect(R(s).HasPath("Address.Lines[0]")).To(BeTrue())
+			Expect(R(s).HasPath("Address.Lines[3]")).To(BeFalse())
+			Expect(R(s).HasPath("Items[0].Street")).To(BeFalse())
+			Expect(R(&pathEmbedding{}).HasPath("X")).To(BeFalse())
+		})
+	})
+
+	Describe("SetPath", func() {
+		It("Should set struct fields", func() {
+			Expect(R(s).SetPath("Address.Lines[1].Street", "new")).ToNot(HaveOccurred())
+			Expect(s.Address.Lines[1].Street).To(Equal("new"))
+		})
+
+		It("Should allocate nil pointers", func() {
+			s.Address = nil
+			Expect(R(s).SetPath("Address.Zip", 10, true)).ToNot(HaveOccurred())
+			Expect(*s.Address.Zip).To(Equal(10))
+		})
+
+		It("Should allocate nil embedded pointers", func() {
+			e := &pathEmbedding{}
+			Expect(R(e).SetPath("X", "3", true)).ToNot(HaveOccurred())
+			Expect(e.PathBase).To(Equal(&PathBase{X: 3}))
+
+			// Unexported embedded pointers can not be allocated.
+			err := R(e).SetPath("Street", "s")
+			Expect(errors.Is(err, ErrNilPointer)).To(BeTrue())
+			Expect(err.(*Error).Path).To(Equal("Street"))
+
+			err = R(pathEmbedding{}).SetPath("X", 3)
+			Expect(errors.Is(err, ErrNilPointer)).To(BeTrue())
+		})
+
+		It("Should allocate nil maps", func() {
+			s.Tags = nil
+			Expect(R(s).SetPath("Tags.x", "y")).ToNot(HaveOccurred())
+			Expect(s.Tags).To(Equal(map[string]string{"x": "y"}))
+		})
+
+		It("Should grow slices", func() {
+			Expect(R(s).SetPath("Items[2].Street", "z")).ToNot(HaveOccurred())
+			Expect(s.Items).To(HaveLen(3))
+			Expect(s.Items[0]).To(BeNil())
+			Expect(s.Items[2].Street).To(Equal("z"))
+		})
+
+		It("Should set nested values in interface maps", func() {
+			Expect(R(s).SetPath("Data.nested.list[3]", "x")).ToNot(HaveOccurred())
+			Expect(R(s).SetPath("Data.other.key", 1)).ToNot(HaveOccurred())
+
+			Expect(s.Data["nested"].(map[string]interface{})["list"]).To(Equal([]interface{}{1, 2, nil, "x"}))
+			Expect(s.Data["other"]).To(Equal(map[string]interface{}{"key": 1}))
+		})
+
+		It("Should set values behind interfaces", func() {
+			Expect(R(s).SetPath("Any.Street", "changed")).ToNot(HaveOccurred())
+			Expect(s.Any).To(Equal(pathLine{"changed"}))
+		})
+
+		It("Should fail with type mismatch unless converting", func() {
+			Expect(R(s).SetPath("Fixed[0]", "7")).To(HaveOccurred())
+			Expect(R(s).SetPath("Fixed[0]", "7", true)).ToNot(HaveOccurred())
+			Expect(s.Fixed[0]).To(Equal(7))
+		})
+
+		It("Should fail on array index out of bounds", func() {
+			err := R(s).SetPath("Fixed[2]", 1)
+			Expect(errors.Is(err, ErrIndexOutOfBounds)).To(BeTrue())
+		})
+
+		It("Should fail when root is not addressable", func() {
+			err := R(*s).SetPath("Name", "x")
+			Expect(errors.Is(err, ErrUnsettableValue)).To(BeTrue())
+		})
+	})
+})
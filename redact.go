@@ -0,0 +1,138 @@
+package reflector
+
+import (
+	"path"
+	"reflect"
+	"strings"
+)
+
+// DefaultRedactPatterns are used by Redact if RedactOptions.Patterns is nil.
+var DefaultRedactPatterns = []string{"*Password", "*Secret", "*Token", "*Key"}
+
+// DefaultRedactMask replaces redacted strings if RedactOptions.Mask is empty.
+const DefaultRedactMask = "[REDACTED]"
+
+// RedactOptions control Redact.
+// A nil *RedactOptions is equivalent to the zero value.
+type RedactOptions struct {
+	// Patterns are matched against struct field names and map keys, ignoring
+	// case. They may contain wildcards, see path.Match.
+	// If nil, DefaultRedactPatterns are used. Use an empty slice to only
+	// redact fields tagged with `reflector:"secret"`.
+	Patterns []string
+
+	// Mask replaces redacted strings. Defaults to DefaultRedactMask.
+	Mask string
+
+	// InPlace modifies the value itself, which must be a pointer, instead
+	// of a copy.
+	InPlace bool
+}
+
+type redactor struct {
+	patterns []string
+	mask     string
+}
+
+func (r *redactor) matches(name string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range r.patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *redactor) secret(node *WalkNode) bool {
+	switch {
+	case node.Field != nil:
+		return hasPackageOption(*node.Field, "secret") || r.matches(node.Field.Name)
+	case node.Key != nil:
+		return r.matches(mapKeyString(node.Key.Value()))
+	}
+	return false
+}
+
+// redact replaces the value of node with the mask if it holds a string,
+// and with the zero value otherwise.
+func (r *redactor) redact(node *WalkNode) error {
+	typ := node.slot.Type()
+	switch {
+	case typ.Kind() == reflect.String:
+		return node.Replace(r.mask, true)
+
+	case typ.Kind() == reflect.Ptr && typ.Elem().Kind() == reflect.String:
+		if node.Value.IsNil() {
+			return nil
+		}
+		ptr := reflect.New(typ.Elem())
+		ptr.Elem().SetString(r.mask)
+		return node.Replace(ptr)
+
+	case typ.Kind() == reflect.Interface && !node.Value.IsNil() && stringType.AssignableTo(typ):
+		return node.Replace(r.mask)
+	}
+	return node.Replace(nil)
+}
+
+func (r *redactor) visit(node *WalkNode) error {
+	if !r.secret(node) {
+		return nil
+	}
+	if err := r.redact(node); err != nil {
+		return err
+	}
+	return ErrSkipNode
+}
+
+// Redact returns a deep copy of value with secrets removed.
+// Struct fields tagged with `reflector:"secret"`, and fields and map entries
+// with a name matching one of the configured patterns, are replaced with a
+// mask if they are strings, and with their zero value otherwise.
+// Nested structs, slices and maps are redacted recursively, so Redact can be
+// used both on structs and on the output of ToMap.
+// With RedactOptions.InPlace, value is modified and returned.
+func Redact(value interface{}, opts *RedactOptions) (interface{}, error) {
+	if opts == nil {
+		opts = &RedactOptions{}
+	}
+
+	patterns := opts.Patterns
+	if patterns == nil {
+		patterns = DefaultRedactPatterns
+	}
+	r := &redactor{
+		patterns: make([]string, len(patterns)),
+		mask:     opts.Mask,
+	}
+	for i, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, &Error{
+				Code:    ERR_INVALID_VALUE,
+				Message: "invalid redact pattern " + pattern,
+				Err:     err,
+			}
+		}
+		r.patterns[i] = pattern
+	}
+	if r.mask == "" {
+		r.mask = DefaultRedactMask
+	}
+
+	target := Reflect(value)
+	if target == nil || !target.IsValid() {
+		return value, nil
+	}
+	if !opts.InPlace {
+		if target = target.DeepCopy(); target == nil {
+			return nil, newError(ERR_INVALID_VALUE)
+		}
+	}
+
+	if err := Walk(target, r.visit); err != nil {
+		return nil, err
+	}
+	return target.Interface(), nil
+}
@@ -0,0 +1,98 @@
+package reflector_test
+
+import (
+	"errors"
+
+	. "github.com/theduke/go-reflector"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type redactCredentials struct {
+	User     string
+	Password string
+	APIKey   *string
+	PIN      int `reflector:"secret"`
+}
+
+type redactRequest struct {
+	Path        string
+	Credentials *redactCredentials
+	Logins      []redactCredentials
+	Headers     map[string]string
+	Body        map[string]interface{}
+	Token       []byte
+}
+
+var _ = Describe("Redact", func() {
+	var req *redactRequest
+
+	BeforeEach(func() {
+		key := "key"
+		req = &redactRequest{
+			Path:        "/login",
+			Credentials: &redactCredentials{User: "u", Password: "p", APIKey: &key, PIN: 1234},
+			Logins:      []redactCredentials{{User: "a", Password: "b"}},
+			Headers:     map[string]string{"Accept": "json", "X-Auth-Token": "t"},
+			Body: map[string]interface{}{
+				"name":    "n",
+				"api_key": 5,
+				"nested":  map[string]interface{}{"password": "p"},
+			},
+			Token: []byte("t"),
+		}
+	})
+
+	It("Should redact a copy", func() {
+		result, err := Redact(req, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		masked := DefaultRedactMask
+		Expect(result).To(Equal(&redactRequest{
+			Path:        "/login",
+			Credentials: &redactCredentials{User: "u", Password: masked, APIKey: &masked},
+			Logins:      []redactCredentials{{User: "a", Password: masked}},
+			Headers:     map[string]string{"Accept": "json", "X-Auth-Token": masked},
+			Body: map[string]interface{}{
+				"name":    "n",
+				"api_key": masked,
+				"nested":  map[string]interface{}{"password": masked},
+			},
+		}))
+
+		// The original is untouched.
+		Expect(req.Credentials.Password).To(Equal("p"))
+		Expect(*req.Credentials.APIKey).To(Equal("key"))
+		Expect(req.Body["nested"]).To(Equal(map[string]interface{}{"password": "p"}))
+	})
+
+	It("Should redact in place", func() {
+		result, err := Redact(req, &RedactOptions{InPlace: true, Mask: "*"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeIdenticalTo(req))
+		Expect(req.Credentials.Password).To(Equal("*"))
+		Expect(req.Credentials.PIN).To(Equal(0))
+	})
+
+	It("Should use the configured patterns", func() {
+		result, err := Redact(req, &RedactOptions{Patterns: []string{"user"}})
+		Expect(err).ToNot(HaveOccurred())
+		redacted := result.(*redactRequest)
+		Expect(redacted.Credentials.User).To(Equal(DefaultRedactMask))
+		Expect(redacted.Credentials.Password).To(Equal("p"))
+		Expect(redacted.Credentials.PIN).To(Equal(0))
+
+		_, err = Redact(req, &RedactOptions{Patterns: []string{"["}})
+		Expect(errors.Is(err, ErrInvalidValue)).To(BeTrue())
+	})
+
+	It("Should redact ToMap output", func() {
+		data := R(req.Credentials).MustStruct().ToMap(false, false)
+		result, err := Redact(data, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(HaveKeyWithValue("Password", DefaultRedactMask))
+		Expect(result).To(HaveKeyWithValue("User", "u"))
+		Expect(data).To(HaveKeyWithValue("Password", "p"))
+	})
+})
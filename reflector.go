@@ -5,7 +5,6 @@
 package reflector
 
 import (
-	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
@@ -15,6 +14,8 @@ import (
 
 const (
 	ERR_UNKNOWN_FIELD         = "unknown_field"
+	ERR_UNKNOWN_KEY           = "unknown_key"
+	ERR_MISSING_FIELD         = "missing_required_field"
 	ERR_INVALID_FIELD         = "invalid_field"
 	ERR_UNINTERFACEABLE_FIELD = "uninterfaceable_field"
 
@@ -25,14 +26,33 @@ const (
 	ERR_INVALID_VALUE              = "invalid_value"
 	ERR_STRUCT_EXPECTED            = "struct_expected"
 	ERR_NIL_POINTER                = "nil_pointer"
+	ERR_NIL_SLICE_POINTER          = "nil_slice_ptr"
 	ERR_NOT_A_STRUCT               = "not_a_struct"
 	ERR_NOT_A_SLICE                = "not_a_slice"
+	ERR_NOT_A_MAP                  = "not_a_map"
+	ERR_NIL_MAP                    = "nil_map"
+	ERR_INVALID_TYPES_OR_NIL_MAP   = "invalid_types_or_nil_map"
 	ERR_UNSETTABLE_VALUE           = "unsettable_value"
 	ERR_TYPE_MISMATCH              = "type_mismatch"
 	ERR_UNCOMPARABLE_VALUES        = "uncomparable_values"
+	ERR_INVALID_COMPARISON         = "invalid_filter_comparison"
 	ERR_UNKNOWN_OPERATOR           = "unknown_operator"
 	ERR_CANT_APPEND_NOT_A_POINTER  = "cant_append_when_slice_reflector_not_created_from_pointer"
 	ERR_INDEX_OUT_OF_BOUNDS        = "index_out_of_bounds"
+	ERR_INVALID_PATH               = "invalid_path"
+	ERR_SKIP_CONVERTER             = "skip_converter"
+	ERR_NUMERIC_OVERFLOW           = "numeric_overflow"
+	ERR_LOSSY_CONVERSION           = "lossy_conversion"
+	ERR_PATCH_TEST_FAILED          = "patch_test_failed"
+	ERR_SKIP_NODE                  = "skip_node"
+	ERR_STOP_WALK                  = "stop_walk"
+	ERR_VALIDATION_FAILED          = "validation_failed"
+	ERR_UNKNOWN_VALIDATOR          = "unknown_validator"
+)
+
+var (
+	stringType = reflect.TypeOf("")
+	timeType   = reflect.TypeOf(time.Time{})
 )
 
 // IsNumericKind returns true if the given reflect.Kind is any numeric type,
@@ -87,7 +107,7 @@ func (r *Reflector) Interface() interface{} {
 		val = r.Elem().Value()
 	}
 
-	if !val.CanInterface() {
+	if !val.IsValid() || !val.CanInterface() {
 		return nil
 	}
 	return val.Interface()
@@ -248,11 +268,11 @@ func (r *Reflector) IsZero() bool {
 	}
 	// Not nil, so compare with the zero type.
 
-	// Prevent comparison with uncomparable types.
+	// Non-nil slices and maps are never zero.
 	if r.IsSlice() || r.IsArray() || r.IsMap() {
 		return false
 	}
-	return r.Interface() == reflect.Zero(r.Type()).Interface()
+	return r.value.IsZero()
 }
 
 func (r *Reflector) DeepIsZero() bool {
@@ -312,6 +332,18 @@ func (r *Reflector) MustStruct() *StructReflector {
 	return s
 }
 
+func (r *Reflector) Map() (*MapReflector, error) {
+	return newMapReflector(r)
+}
+
+func (r *Reflector) MustMap() *MapReflector {
+	m, err := r.Map()
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
 func (r *Reflector) NewSlice() *SliceReflector {
 	// Build new array.
 	// See http://stackoverflow.com/questions/25384640/why-golang-reflect-makeslice-returns-un-addressable-value
@@ -339,7 +371,7 @@ func (r *Reflector) ConvertTo(targetVal interface{}) (interface{}, error) {
 	// Check for empty value, to prevent a panic when user
 	// passes in nil for example.
 	if !reflect.ValueOf(targetVal).IsValid() {
-		return nil, errors.New(ERR_INVALID_VALUE)
+		return nil, newError(ERR_INVALID_VALUE)
 	}
 	return r.ConvertToType(reflect.TypeOf(targetVal))
 }
@@ -353,6 +385,17 @@ func (r *Reflector) saveConvertToType(typ reflect.Type) interface{} {
 }
 
 func (r *Reflector) ConvertToType(typ reflect.Type) (interface{}, error) {
+	return r.ConvertToTypeWith(typ, nil)
+}
+
+// ConvertToTypeWith converts the value to the given type, using the
+// given options.
+func (r *Reflector) ConvertToTypeWith(typ reflect.Type, opts *ConvertOptions) (interface{}, error) {
+	// Without options, slice items only get default and Go conversions.
+	sliceOpts := opts
+	if opts == nil {
+		opts = &ConvertOptions{}
+	}
 	kind := typ.Kind()
 
 	valKind := r.Type().Kind()
@@ -362,13 +405,18 @@ func (r *Reflector) ConvertToType(typ reflect.Type) (interface{}, error) {
 		return r.Interface(), nil
 	}
 
+	// Registered converters take precedence over the builtin rules.
+	if converted, ok, err := customConvert(r, typ, opts); ok {
+		return converted, err
+	}
+
 	// If value and target are slices, but of differing type, try to convert.
 	if r.IsSlice() && typ.Kind() == reflect.Slice {
 		sliceR, err := r.Slice()
 		if err != nil {
 			return nil, err
 		}
-		newSlice, err := sliceR.ConvertToType(typ.Elem())
+		newSlice, err := sliceR.ConvertToTypeWith(typ.Elem(), sliceOpts)
 		if err != nil {
 			return nil, err
 		}
@@ -396,22 +444,15 @@ func (r *Reflector) ConvertToType(typ reflect.Type) (interface{}, error) {
 		return r.Elem().Interface(), nil
 	}
 
-	// Parse dates into time.Time.
-
-	isTime := kind == reflect.Struct && typ.PkgPath() == "time" && typ.Name() == "Time"
-	isTimePointer := isPointer && pointerType.Kind() == reflect.Struct && pointerType.PkgPath() == "time" && pointerType.Name() == "Time"
-
-	if (isTime || isTimePointer) && valKind == reflect.String {
-		date, err := time.Parse(time.RFC3339, r.Interface().(string))
-		if err != nil {
-			return nil, errors.New(ERR_INVALID_TIME)
-		}
+	// Parse times, Unix timestamps and durations.
+	if converted, ok, err := convertTime(r, typ, opts); ok {
+		return converted, err
+	}
 
-		if isTime {
-			return date, nil
-		} else {
-			return &date, nil
-		}
+	// Let types implementing encoding.TextUnmarshaler or
+	// encoding.BinaryUnmarshaler decode themselves.
+	if converted, ok, err := unmarshalConvert(r, typ); ok {
+		return converted, err
 	}
 
 	// Special handling for bool to string.
@@ -427,11 +468,24 @@ func (r *Reflector) ConvertToType(typ reflect.Type) (interface{}, error) {
 
 	// Special handling for string target.
 	if kind == reflect.String {
+		// Prefer encoding.TextMarshaler over fmt.Stringer.
+		if text, ok, err := marshalText(r); ok {
+			if err != nil {
+				return nil, err
+			}
+			return reflect.ValueOf(text).Convert(typ).Interface(), nil
+		}
+
 		// Convert byte array to string.
 		if bytes, ok := r.Interface().([]byte); ok {
 			return string(bytes), nil
 		}
 
+		// Format time with the configured layout.
+		if t, ok := r.Interface().(time.Time); ok && opts.TimeFormat != "" {
+			return t.Format(opts.TimeFormat), nil
+		}
+
 		// Check if type implemens stringer interface.
 		if stringer, ok := r.Interface().(fmt.Stringer); ok {
 			// Implements Stringer, so use .String().
@@ -442,12 +496,22 @@ func (r *Reflector) ConvertToType(typ reflect.Type) (interface{}, error) {
 		return fmt.Sprintf("%v", r.Interface()), nil
 	}
 
+	// Strict mode only allows lossless numeric conversions.
+	if opts.Mode == ConvertStrict && IsNumericKind(kind) && (r.IsNumeric() || r.IsString()) {
+		return convertNumber(r, typ)
+	}
+
 	// If value is string, and target type is numeric,
 	// parse to float and then convert with reflect.
 	if valKind == reflect.String && IsNumericKind(kind) {
 		num, err := strconv.ParseFloat(r.Interface().(string), 64)
 		if err != nil {
-			return nil, err
+			return nil, &Error{
+				Code:   ERR_UNCONVERTABLE_TYPES,
+				Source: r.Type(),
+				Target: typ,
+				Err:    err,
+			}
 		}
 		return reflect.ValueOf(num).Convert(typ).Interface(), nil
 	}
@@ -455,31 +519,47 @@ func (r *Reflector) ConvertToType(typ reflect.Type) (interface{}, error) {
 	// No custom handling worked, so try to convert with reflect.
 	converted := r.saveConvertToType(typ)
 	if converted == nil {
-		return nil, errors.New(ERR_UNCONVERTABLE_TYPES)
+		return nil, &Error{
+			Code:   ERR_UNCONVERTABLE_TYPES,
+			Source: r.Type(),
+			Target: typ,
+		}
 	}
 
 	return converted, nil
 }
 
 func (r *Reflector) Set(value *Reflector, convert ...bool) error {
+	return r.SetWith(value, convertOptions(convert))
+}
+
+// SetWith sets the value, converting it according to the given options
+// if the types differ.
+func (r *Reflector) SetWith(value *Reflector, opts *ConvertOptions) error {
 	if value == nil {
-		return errors.New(ERR_INVALID_VALUE)
+		return newError(ERR_INVALID_VALUE)
 	} else if !value.IsValid() {
-		return errors.New(ERR_INVALID_VALUE)
+		return newError(ERR_INVALID_VALUE)
 	} else if !r.value.CanSet() {
-		return errors.New(ERR_UNSETTABLE_VALUE)
+		return &Error{
+			Code:   ERR_UNSETTABLE_VALUE,
+			Target: r.Type(),
+		}
 	}
-	doConvert := len(convert) > 0 && convert[0]
 	if value.Type() != r.Type() {
-		if doConvert {
+		if opts.converts() {
 			// Try to convert.
-			converted, err := value.ConvertToType(r.Type())
+			converted, err := value.ConvertToTypeWith(r.Type(), opts)
 			if err != nil {
 				return err
 			}
 			value = Reflect(converted)
 		} else {
-			return errors.New(ERR_TYPE_MISMATCH)
+			return &Error{
+				Code:   ERR_TYPE_MISMATCH,
+				Source: value.Type(),
+				Target: r.Type(),
+			}
 		}
 	}
 	r.value.Set(value.Value())
@@ -489,7 +569,7 @@ func (r *Reflector) Set(value *Reflector, convert ...bool) error {
 func (r *Reflector) SetValue(rawValue interface{}, convert ...bool) error {
 	val := Reflect(rawValue)
 	if !val.IsValid() {
-		return errors.New(ERR_INVALID_VALUE)
+		return newError(ERR_INVALID_VALUE)
 	}
 
 	return r.Set(val, convert...)
@@ -505,20 +585,30 @@ func saveExecute(f func()) bool {
 }
 
 func (r *Reflector) SetMapKey(key *Reflector, value *Reflector, convert ...bool) error {
+	return r.SetMapKeyWith(key, value, convertOptions(convert))
+}
+
+// SetMapKeyWith sets a map key, converting the value according to the
+// given options if the types differ.
+func (r *Reflector) SetMapKeyWith(key *Reflector, value *Reflector, opts *ConvertOptions) error {
 	if !r.IsMap() {
-		return errors.New("not_a_map")
+		return newError(ERR_NOT_A_MAP)
 	}
 
 	valueType := r.Type().Elem()
 	if value.Type() != valueType {
-		if len(convert) > 0 && convert[0] {
-			v, err := value.ConvertToType(valueType)
+		if opts.converts() {
+			v, err := value.ConvertToTypeWith(valueType, opts)
 			if err != nil {
 				return err
 			}
 			value = R(v)
 		} else {
-			return errors.New(ERR_TYPE_MISMATCH)
+			return &Error{
+				Code:   ERR_TYPE_MISMATCH,
+				Source: value.Type(),
+				Target: valueType,
+			}
 		}
 	}
 
@@ -527,7 +617,7 @@ func (r *Reflector) SetMapKey(key *Reflector, value *Reflector, convert ...bool)
 	})
 
 	if !success {
-		return errors.New("invalid_types_or_nil_map")
+		return newError(ERR_INVALID_TYPES_OR_NIL_MAP)
 	}
 
 	return nil
@@ -537,7 +627,26 @@ func (r *Reflector) SetStrMapKeyValue(key string, value interface{}, convert ...
 	return r.SetMapKey(R(key), R(value), convert...)
 }
 
-func compareStringValues(condition, a, b string) (bool, error) {
+func compareStringValues(condition, a, b string, opts *CompareOptions) (bool, error) {
+	if condition == "regex" || condition == "!regex" {
+		pattern := b
+		if opts.IgnoreCase {
+			pattern = "(?i)" + pattern
+		}
+		re, err := compileRegex(pattern)
+		if err != nil {
+			return false, &Error{
+				Code:    ERR_INVALID_COMPARISON,
+				Message: "invalid regular expression " + b,
+				Err:     err,
+			}
+		}
+		return re.MatchString(a) == (condition == "regex"), nil
+	}
+
+	a = opts.prepareString(a)
+	b = opts.prepareString(b)
+
 	// Check different possible filters.
 	switch condition {
 	case "=", "==":
@@ -546,18 +655,23 @@ func compareStringValues(condition, a, b string) (bool, error) {
 		return a != b, nil
 	case "like":
 		return strings.Contains(a, b), nil
+	case "ilike":
+		return strings.Contains(strings.ToLower(a), strings.ToLower(b)), nil
+	case "prefix":
+		return strings.HasPrefix(a, b), nil
+	case "suffix":
+		return strings.HasSuffix(a, b), nil
 	case "<":
-		return a < b, nil
+		return opts.compareStrings(a, b) < 0, nil
 	case "<=":
-		return a <= b, nil
+		return opts.compareStrings(a, b) <= 0, nil
 	case ">":
-		return a > b, nil
+		return opts.compareStrings(a, b) > 0, nil
 	case ">=":
-		return a >= b, nil
+		return opts.compareStrings(a, b) >= 0, nil
 	}
 
-	// Should never happen, since .CompareTo checks the operator.
-	panic("Unknown operator: " + condition)
+	return false, &Error{Code: ERR_UNKNOWN_OPERATOR, Message: condition}
 }
 
 func compareFloat64Values(condition string, a, b float64) (bool, error) {
@@ -567,8 +681,11 @@ func compareFloat64Values(condition string, a, b float64) (bool, error) {
 		return a == b, nil
 	case "!=":
 		return a != b, nil
-	case "like":
-		return false, errors.New("invalid_filter_comparison: LIKE filter can only be used for string values, not numbers")
+	case "like", "ilike", "prefix", "suffix", "regex", "!regex":
+		return false, &Error{
+			Code:    ERR_INVALID_COMPARISON,
+			Message: strings.ToUpper(condition) + " filter can only be used for string values, not numbers",
+		}
 	case "<":
 		return a < b, nil
 	case "<=":
@@ -579,95 +696,90 @@ func compareFloat64Values(condition string, a, b float64) (bool, error) {
 		return a >= b, nil
 	}
 
-	// Should never happen, since .CompareTo checks the operator.
-	panic("Unknown operator: " + condition)
+	return false, &Error{Code: ERR_UNKNOWN_OPERATOR, Message: condition}
 }
 
+// CompareTo compares the value to another value with an operator.
+//
+// Numbers, numeric strings, durations and times are compared numerically,
+// other strings lexically. Pointers are de-referenced.
+// Supported operators are =, ==, !=, <, <=, >, >= and these string operators:
+// like (substring), ilike (case-insensitive substring), prefix, suffix,
+// regex and !regex.
+//
+// The operators in, not in and between take a slice or array as value,
+// like []int{1, 10} for between, which includes both bounds.
+// contains and contains any check the items of a slice or array value
+// against a single value or a slice of values.
+// is null and not null ignore the value and check if the value is nil.
+//
+// nil is distinct from zero values, and all comparisons with nil are false,
+// like in SQL, except for is null. See CompareOptions for the legacy
+// behavior, and for case-insensitive and natural string comparisons.
+//
+// Custom operators registered with RegisterOperator take precedence over
+// the builtin operators.
 func (r *Reflector) CompareTo(value interface{}, operator string) (bool, error) {
-	// Check operator.
-	switch operator {
-	case "=", "!=", "like", "<", "<=", ">", ">=":
-	case "==":
-		operator = "="
-	default:
-		return false, errors.New(ERR_UNKNOWN_OPERATOR)
-	}
-
-	a := r
-	aVal := r.Interface()
-	if !a.IsValid() || a.DeepIsZero() {
-		aVal = float64(0)
-		a = Reflect(aVal)
-	}
-	if a.IsPtr() {
-		a = a.Elem()
-		aVal = a.Interface()
-	}
-	typA := a.Type()
-	kindA := typA.Kind()
+	return r.CompareToWith(value, operator, nil)
+}
 
-	bVal := value
-	var b *Reflector
-	if r, ok := value.(*Reflector); ok {
-		// Value is a reflector, so use it.
-		b = r
-		bVal = b.Interface()
-	} else {
-		b = Reflect(value)
+// CompareToWith compares the value to another value with an operator,
+// using the given options.
+// See CompareTo.
+func (r *Reflector) CompareToWith(value interface{}, operator string, opts *CompareOptions) (bool, error) {
+	if opts == nil {
+		opts = &CompareOptions{}
 	}
-	if !b.IsValid() || b.DeepIsZero() {
-		bVal = float64(0)
-		b = Reflect(bVal)
-		aVal, bVal = bVal, aVal
-		a, b = b, a
+	if operator == "==" {
+		operator = "="
 	}
-	if b.IsPtr() {
-		b = b.Elem()
-		bVal = b.Interface()
+	if result, ok, err := r.customCompare(value, operator, opts); ok {
+		return result, err
 	}
-	typB := b.Type()
-	kindB := typB.Kind()
 
-	// Compare time.Time values numerically.
-	if kindA == reflect.Struct && typA.PkgPath() == "time" && typA.Name() == "Time" {
-		t := aVal.(time.Time)
-		aVal = float64(t.UnixNano())
-		a = Reflect(aVal)
-		typA = a.Type()
-		kindA = typA.Kind()
+	// Check operator.
+	switch operator {
+	case "=", "!=", "like", "ilike", "prefix", "suffix", "regex", "!regex", "<", "<=", ">", ">=":
+	case "in", "not in", "between", "contains", "contains any", "is null", "not null":
+		return r.compareCollection(value, operator, opts)
+	default:
+		return false, &Error{
+			Code:    ERR_UNKNOWN_OPERATOR,
+			Message: operator,
+		}
 	}
 
-	if kindB == reflect.Struct && typB.PkgPath() == "time" && typB.Name() == "Time" {
-		t := bVal.(time.Time)
-		bVal = float64(t.UnixNano())
-		b = Reflect(bVal)
-		typB = b.Type()
-		kindB = typB.Kind()
+	a := deref(r)
+	b := deref(Reflect(reflectValue(value)))
+	if opts.CoerceNil {
+		// Legacy behavior: nil and zero values are compared as 0.
+		if a.IsNil() || a.DeepIsZero() {
+			a = Reflect(float64(0))
+		}
+		if b.IsNil() || b.DeepIsZero() {
+			b = Reflect(float64(0))
+		}
+	} else if a.IsNil() || b.IsNil() {
+		return false, nil
 	}
 
-	// Convert time.Duration to float64.
-	if d, ok := aVal.(time.Duration); ok {
-		aVal = float64(d)
-		a = Reflect(aVal)
-		typA = a.Type()
-		kindA = typA.Kind()
-	}
-	if d, ok := bVal.(time.Duration); ok {
-		bVal = float64(d)
-		b = Reflect(aVal)
-		typB = b.Type()
-		kindB = typB.Kind()
-	}
+	// Compare times and durations numerically.
+	a = timeNumber(a)
+	b = timeNumber(b)
+	typA := a.Type()
+	typB := b.Type()
+	kindA := typA.Kind()
+	kindB := typB.Kind()
 
 	if IsNumericKind(kindA) || IsNumericKind(kindB) {
 		numA, err := a.ConvertTo(float64(0))
 		if err != nil {
-			return false, errors.New("Conversion error: " + err.Error())
+			return false, comparisonError(a, b, err)
 		}
 
 		numB, err := b.ConvertTo(float64(0))
 		if err != nil {
-			return false, errors.New("Conversion error: " + err.Error())
+			return false, comparisonError(a, b, err)
 		}
 
 		return compareFloat64Values(operator, numA.(float64), numB.(float64))
@@ -676,15 +788,15 @@ func (r *Reflector) CompareTo(value interface{}, operator string) (bool, error)
 	if kindA == reflect.String {
 		convertedB, err := b.ConvertTo("")
 		if err != nil {
-			return false, errors.New("Conversion error: " + err.Error())
+			return false, comparisonError(a, b, err)
 		}
-		return compareStringValues(operator, aVal.(string), convertedB.(string))
+		return compareStringValues(operator, a.value.String(), convertedB.(string), opts)
 	}
 
 	if operator == "=" || operator == "!=" {
 		convertedB, err := b.ConvertToType(typA)
 		if err != nil {
-			return false, errors.New("Conversion error: " + err.Error())
+			return false, comparisonError(a, b, err)
 		}
 
 		if operator == "=" {
@@ -694,6 +806,32 @@ func (r *Reflector) CompareTo(value interface{}, operator string) (bool, error)
 		}
 	}
 
-	msg := "impossible_comparison: " + fmt.Sprintf("Cannot compare type %v(value %v) to type %v(value %v)", kindA, aVal, kindB, bVal)
-	return false, errors.New(msg)
+	return false, &Error{
+		Code:    ERR_UNCOMPARABLE_VALUES,
+		Source:  typA,
+		Target:  typB,
+		Message: fmt.Sprintf("Cannot compare value %v to value %v", a.Interface(), b.Interface()),
+	}
+}
+
+// timeNumber converts time.Time and time.Duration values to float64, so
+// they are compared numerically.
+func timeNumber(r *Reflector) *Reflector {
+	switch v := r.Interface().(type) {
+	case time.Time:
+		return Reflect(float64(v.UnixNano()))
+	case time.Duration:
+		return Reflect(float64(v))
+	}
+	return r
+}
+
+// comparisonError wraps a conversion error that happened during a comparison.
+func comparisonError(a, b *Reflector, err error) error {
+	return &Error{
+		Code:   ERR_UNCOMPARABLE_VALUES,
+		Source: a.Type(),
+		Target: b.Type(),
+		Err:    err,
+	}
 }
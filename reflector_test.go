@@ -208,6 +208,11 @@ var _ = Describe("Reflector", func() {
 			}).IsZero()).To(BeFalse())
 		})
 
+		It("Should not panic on uncomparable structs with .IsZero()", func() {
+			Expect(Reflect(struct{ S []int }{}).IsZero()).To(BeTrue())
+			Expect(Reflect(struct{ S []int }{S: []int{1}}).IsZero()).To(BeFalse())
+		})
+
 		It("Should detect zero values with .DeepIsZero()", func() {
 			Expect(Reflect(0).DeepIsZero()).To(BeTrue())
 			Expect(Reflect(1).DeepIsZero()).To(BeFalse())
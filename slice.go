@@ -1,7 +1,6 @@
 package reflector
 
 import (
-	"errors"
 	"reflect"
 	"sort"
 )
@@ -14,7 +13,7 @@ type SliceReflector struct {
 
 func newSliceReflector(value *Reflector) (*SliceReflector, error) {
 	if !value.IsValid() {
-		return nil, errors.New(ERR_INVALID_VALUE)
+		return nil, newError(ERR_INVALID_VALUE)
 	}
 
 	if value.IsSlice() {
@@ -27,7 +26,10 @@ func newSliceReflector(value *Reflector) (*SliceReflector, error) {
 
 	if value.IsPtr() && value.Type().Elem().Kind() == reflect.Slice {
 		if value.IsNil() {
-			return nil, errors.New("nil_slice_ptr: Can't get a slice reflector for a nil slice pointer. Must pass an initialized pointer!")
+			return nil, &Error{
+				Code:    ERR_NIL_SLICE_POINTER,
+				Message: "Can't get a slice reflector for a nil slice pointer. Must pass an initialized pointer!",
+			}
 		} else {
 			if value.Elem().IsNil() {
 				sliceItemType := value.Type().Elem().Elem()
@@ -47,7 +49,7 @@ func newSliceReflector(value *Reflector) (*SliceReflector, error) {
 		}
 	}
 
-	return nil, errors.New(ERR_NOT_A_SLICE)
+	return nil, newError(ERR_NOT_A_SLICE)
 }
 
 func (s *SliceReflector) String() string {
@@ -95,10 +97,10 @@ func (s *SliceReflector) IndexValue(i int) interface{} {
 
 func (s *SliceReflector) SetIndex(index int, value *Reflector) error {
 	if index > s.Cap()-1 {
-		return errors.New(ERR_INDEX_OUT_OF_BOUNDS)
+		return &Error{Code: ERR_INDEX_OUT_OF_BOUNDS, Path: indexPath(index)}
 	}
 	if err := s.Index(index).Set(value); err != nil {
-		return err
+		return prefixPath(err, indexPath(index))
 	}
 	return nil
 }
@@ -108,8 +110,11 @@ func (s *SliceReflector) SetIndexValue(index int, value interface{}) error {
 }
 
 func (s *SliceReflector) Swap(index1, index2 int) error {
-	if index1 > s.Cap()-1 || index2 > s.Cap()-1 {
-		return errors.New(ERR_INDEX_OUT_OF_BOUNDS)
+	if index1 > s.Cap()-1 {
+		return &Error{Code: ERR_INDEX_OUT_OF_BOUNDS, Path: indexPath(index1)}
+	}
+	if index2 > s.Cap()-1 {
+		return &Error{Code: ERR_INDEX_OUT_OF_BOUNDS, Path: indexPath(index2)}
 	}
 	v1 := s.Index(index1).Interface()
 	v2 := s.Index(index2).Interface()
@@ -136,21 +141,24 @@ func (s *SliceReflector) Items() []*Reflector {
 
 func (s *SliceReflector) Append(values ...*Reflector) error {
 	if !s.canAppend {
-		return errors.New(ERR_CANT_APPEND_NOT_A_POINTER)
+		return newError(ERR_CANT_APPEND_NOT_A_POINTER)
 	}
 
-	var newSlice reflect.Value
+	newSlice := s.sliceValue.Value()
 
 	for _, val := range values {
 		if val.Type() != s.Type() {
-			return errors.New(ERR_TYPE_MISMATCH)
+			return &Error{
+				Code:   ERR_TYPE_MISMATCH,
+				Source: val.Type(),
+				Target: s.Type(),
+			}
 		}
 
-		newSlice = reflect.Append(s.sliceValue.Value(), val.Value())
+		newSlice = reflect.Append(newSlice, val.Value())
 	}
 
-	s.value.Elem().Set(Reflect(newSlice))
-	return nil
+	return s.value.Elem().Set(Reflect(newSlice))
 }
 
 func (s *SliceReflector) AppendValue(values ...interface{}) error {
@@ -165,20 +173,37 @@ func (s *SliceReflector) AppendValue(values ...interface{}) error {
 func (s *SliceReflector) ConvertTo(value interface{}) (interface{}, error) {
 	r := Reflect(value)
 	if r == nil {
-		return nil, errors.New(ERR_INVALID_VALUE)
+		return nil, newError(ERR_INVALID_VALUE)
 	}
 	return s.ConvertToType(r.Type())
 }
 
+// ConvertToType converts the slice to a slice of the given item type.
+// Items are converted with the converters in DefaultConverters, or with Go
+// conversions, see reflect.Value.Convert.
 func (s *SliceReflector) ConvertToType(typ reflect.Type) (interface{}, error) {
+	return s.ConvertToTypeWith(typ, nil)
+}
+
+// ConvertToTypeWith converts the slice to a slice of the given item type,
+// converting items with Reflector.ConvertToTypeWith and the given options.
+// A nil opts only applies DefaultConverters and Go conversions, like
+// ConvertToType.
+func (s *SliceReflector) ConvertToTypeWith(typ reflect.Type, opts *ConvertOptions) (interface{}, error) {
 	newSlice := New(typ).Elem().NewSlice()
 	if s.Len() == 0 {
-		return newSlice, nil
+		return newSlice.Interface(), nil
 	}
 
-	for _, item := range s.Items() {
+	for index, item := range s.Items() {
 		// De-reference interfaces.
 		if item.IsInterface() {
+			if item.IsNil() {
+				if err := newSlice.Append(Reflect(reflect.Zero(typ))); err != nil {
+					return nil, err
+				}
+				continue
+			}
 			item = item.Elem()
 		}
 
@@ -187,11 +212,29 @@ func (s *SliceReflector) ConvertToType(typ reflect.Type) (interface{}, error) {
 			item = item.Elem()
 		}
 
-		if item.Type() != typ {
-			if !item.Type().ConvertibleTo(typ) {
-				return nil, errors.New(ERR_TYPE_MISMATCH)
+		if item.Type() != typ && opts == nil {
+			converted, ok, err := customConvert(item, typ, nil)
+			if err != nil {
+				return nil, prefixPath(err, indexPath(index))
+			}
+			if ok {
+				item = Reflect(reflect.ValueOf(converted).Convert(typ))
+			} else if !item.Type().ConvertibleTo(typ) {
+				return nil, &Error{
+					Code:   ERR_TYPE_MISMATCH,
+					Path:   indexPath(index),
+					Source: item.Type(),
+					Target: typ,
+				}
+			} else {
+				item = Reflect(item.Value().Convert(typ))
 			}
-			item = Reflect(item.Value().Convert(typ))
+		} else if item.Type() != typ {
+			converted, err := item.ConvertToTypeWith(typ, opts)
+			if err != nil {
+				return nil, prefixPath(err, indexPath(index))
+			}
+			item = Reflect(reflect.ValueOf(converted).Convert(typ))
 		}
 
 		if err := newSlice.Append(item); err != nil {
@@ -227,7 +270,7 @@ func (s *SliceReflector) SortBy(sorterFunc func(a, b *Reflector) (bool, error))
 		return nil
 	}
 
-	sorter := sliceSorter{
+	sorter := &sliceSorter{
 		slice:    s,
 		items:    s.Items(),
 		sortFunc: sorterFunc,
@@ -249,13 +292,16 @@ func (s *SliceReflector) SortByFieldFunc(fieldName string, sorterFunc func(a, b
 	firstItem := s.Index(0)
 
 	if !(firstItem.IsStructPtr() || firstItem.IsStruct() || firstItem.IsMap()) {
-		return errors.New("Can't sort by field when slice items are neither pointers to structs, structs or maps")
+		return &Error{
+			Code:    ERR_POINTER_OR_STRUCT_EXPECTED,
+			Message: "Can't sort by field when slice items are neither pointers to structs, structs or maps",
+		}
 	}
 
 	if firstItem.IsStructPtr() || firstItem.IsStruct() {
 		// Check that struct field exists.
 		if !firstItem.MustStruct().HasField(fieldName) {
-			return errors.New(ERR_UNKNOWN_FIELD)
+			return &Error{Code: ERR_UNKNOWN_FIELD, Path: fieldName}
 		}
 	}
 
@@ -269,7 +315,7 @@ func (s *SliceReflector) SortByFieldFunc(fieldName string, sorterFunc func(a, b
 
 	fieldNameVal := reflect.ValueOf(fieldName)
 
-	sorter := sliceSorter{
+	sorter := &sliceSorter{
 		slice: s,
 		items: s.Items(),
 		sortFunc: func(a, b *Reflector) (bool, error) {
@@ -311,13 +357,20 @@ func (s *SliceReflector) SortByFieldFunc(fieldName string, sorterFunc func(a, b
 }
 
 func (s *SliceReflector) SortByField(fieldName string, ascending bool) error {
+	return s.SortByFieldWith(fieldName, ascending, nil)
+}
+
+// SortByFieldWith sorts the slice by a field, comparing the values with
+// CompareToWith and the given options, for example to sort strings in
+// natural order.
+func (s *SliceReflector) SortByFieldWith(fieldName string, ascending bool, opts *CompareOptions) error {
 	operator := "<"
 	if !ascending {
 		operator = ">"
 	}
 
 	sorter := func(a, b *Reflector) (bool, error) {
-		return a.CompareTo(b, operator)
+		return a.CompareToWith(b, operator, opts)
 	}
 
 	return s.SortByFieldFunc(fieldName, sorter)
@@ -330,15 +383,15 @@ type sliceSorter struct {
 	err      error
 }
 
-func (s sliceSorter) Len() int {
+func (s *sliceSorter) Len() int {
 	return s.slice.Len()
 }
 
-func (s sliceSorter) Swap(i, j int) {
+func (s *sliceSorter) Swap(i, j int) {
 	s.slice.Swap(i, j)
 }
 
-func (s sliceSorter) Less(i, j int) bool {
+func (s *sliceSorter) Less(i, j int) bool {
 	flag, err := s.sortFunc(s.items[i], s.items[j])
 	if err != nil {
 		s.err = err
@@ -1,6 +1,9 @@
 package reflector_test
 
 import (
+	"errors"
+	"reflect"
+
 	. "github.com/theduke/go-reflector"
 
 	. "github.com/onsi/ginkgo"
@@ -92,6 +95,16 @@ var _ = Describe("Slice", func() {
 		Expect(s).To(Equal([]int{5, 10, 15, 20}))
 	})
 
+	It("Should .Append() multiple values", func() {
+		s := []int{1}
+		r, _ := Reflect(&s).Slice()
+		Expect(r.Append(Reflect(2), Reflect(3))).ToNot(HaveOccurred())
+		Expect(s).To(Equal([]int{1, 2, 3}))
+
+		Expect(r.Append()).ToNot(HaveOccurred())
+		Expect(s).To(Equal([]int{1, 2, 3}))
+	})
+
 	It("Should convert interface slice to int", func() {
 		s := []interface{}{0, 1, 2, 3}
 		Expect(Reflect(s).MustSlice().ConvertTo(0)).To(Equal([]int{0, 1, 2, 3}))
@@ -263,4 +276,44 @@ var _ = Describe("Slice", func() {
 		Expect(r.SortByField("Int", false)).ToNot(HaveOccurred())
 		Expect(items).To(BeEquivalentTo(sortedItems))
 	})
+
+	It("Should sort by field with .SortByFieldWith()", func() {
+		type S struct{ Name string }
+		items := []S{{"item10"}, {"Item2"}, {"item1"}}
+
+		r := R(items).MustSlice()
+
+		Expect(r.SortByField("Name", true)).ToNot(HaveOccurred())
+		Expect(items).To(Equal([]S{{"Item2"}, {"item1"}, {"item10"}}))
+
+		opts := &CompareOptions{Natural: true, IgnoreCase: true}
+		Expect(r.SortByFieldWith("Name", true, opts)).ToNot(HaveOccurred())
+		Expect(items).To(Equal([]S{{"item1"}, {"Item2"}, {"item10"}}))
+
+		Expect(r.SortByFieldWith("Name", false, opts)).ToNot(HaveOccurred())
+		Expect(items).To(Equal([]S{{"item10"}, {"Item2"}, {"item1"}}))
+	})
+
+	It("Should return sort errors", func() {
+		items := []interface{}{1, "a", 2}
+		err := R(items).MustSlice().SortBy(func(a, b *Reflector) (bool, error) {
+			return a.CompareTo(b, "<")
+		})
+		Expect(errors.Is(err, ErrUncomparableValues)).To(BeTrue())
+	})
+
+	It("Should convert items with .ConvertToType()", func() {
+		intType := reflect.TypeOf(0)
+
+		converted, err := R([]float64{1, 2}).MustSlice().ConvertToType(intType)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(converted).To(Equal([]int{1, 2}))
+
+		_, err = R([]string{"1"}).MustSlice().ConvertToType(intType)
+		Expect(errors.Is(err, ErrTypeMismatch)).To(BeTrue())
+
+		converted, err = R([]string{"1"}).MustSlice().ConvertToTypeWith(intType, &ConvertOptions{Mode: ConvertLenient})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(converted).To(Equal([]int{1}))
+	})
 })
@@ -1,8 +1,9 @@
 package reflector
 
 import (
-	"errors"
 	"reflect"
+	"sort"
+	"strings"
 )
 
 type StructReflector struct {
@@ -15,13 +16,13 @@ type StructReflector struct {
 // You may pass in a struct or a pointer to a struct.
 func newStructReflector(v *Reflector) (*StructReflector, error) {
 	if !v.IsValid() {
-		return nil, errors.New(ERR_INVALID_VALUE)
+		return nil, newError(ERR_INVALID_VALUE)
 	}
 
 	// Dereference interfaces.
 	if v.IsInterface() {
 		if v.IsNil() {
-			return nil, errors.New(ERR_INVALID_VALUE)
+			return nil, newError(ERR_INVALID_VALUE)
 		}
 		v = v.Elem()
 	}
@@ -45,7 +46,7 @@ func newStructReflector(v *Reflector) (*StructReflector, error) {
 			isPtr:      true,
 		}, nil
 	}
-	return nil, errors.New(ERR_NOT_A_STRUCT)
+	return nil, newError(ERR_NOT_A_STRUCT)
 }
 
 func (r *StructReflector) Interface() interface{} {
@@ -142,15 +143,15 @@ func (r *StructReflector) HasField(fieldName string) bool {
 
 func (r *StructReflector) FieldValue(fieldName string) (interface{}, error) {
 	if !r.HasField(fieldName) {
-		return nil, errors.New(ERR_UNKNOWN_FIELD)
+		return nil, &Error{Code: ERR_UNKNOWN_FIELD, Path: fieldName}
 	}
 
 	field := r.structItem.Value().FieldByName(fieldName)
 	if !field.IsValid() {
-		return nil, errors.New(ERR_INVALID_FIELD)
+		return nil, &Error{Code: ERR_INVALID_FIELD, Path: fieldName}
 	}
 	if !field.CanInterface() {
-		return nil, errors.New(ERR_UNINTERFACEABLE_FIELD)
+		return nil, &Error{Code: ERR_UNINTERFACEABLE_FIELD, Path: fieldName}
 	}
 	return field.Interface(), nil
 }
@@ -164,87 +165,521 @@ func (r *StructReflector) UFieldValue(fieldName string) interface{} {
 }
 
 func (r *StructReflector) SetFieldValue(fieldName string, value interface{}, convert ...bool) error {
-	v := Reflect(value)
+	return r.SetFieldWith(fieldName, value, convertOptions(convert))
+}
+
+// SetFieldWith sets a field value, converting it according to the
+// given options if the types differ.
+func (r *StructReflector) SetFieldWith(fieldName string, value interface{}, opts *ConvertOptions) error {
+	v, ok := value.(*Reflector)
+	if !ok {
+		v = Reflect(value)
+	}
 	if v == nil {
-		return errors.New(ERR_INVALID_VALUE)
+		return &Error{Code: ERR_INVALID_VALUE, Path: fieldName}
 	}
-	return r.SetField(fieldName, v, convert...)
-}
 
-func (r *StructReflector) SetField(fieldName string, value *Reflector, convert ...bool) error {
 	field := r.Field(fieldName)
 	if field == nil {
-		return errors.New(ERR_UNKNOWN_FIELD)
+		return &Error{Code: ERR_UNKNOWN_FIELD, Path: fieldName}
 	}
-	return field.Set(value, convert...)
+	return prefixPath(field.SetWith(v, opts), fieldName)
 }
 
+func (r *StructReflector) SetField(fieldName string, value *Reflector, convert ...bool) error {
+	return r.SetFieldWith(fieldName, value, convertOptions(convert))
+}
+
+// ToMap recursively converts the struct to a map, keyed by field names.
+// Embedded structs are flattened into the parent map.
+// Nested structs, slices, arrays and maps are converted to a tree of
+// map[string]interface{}, []interface{} and scalar values.
 func (r *StructReflector) ToMap(omitZero, omitEmpty bool) map[string]interface{} {
+	return r.ToMapWith(&MapOptions{
+		Zero: zeroPolicy(omitZero, omitEmpty),
+	})
+}
+
+// ToTaggedMap works like ToMap, but reads map keys from the given struct tag,
+// like "json" or "db".
+// Fields without a tag use the field name, fields tagged with "-" are skipped.
+// The tag options "omitempty", "string" and "inline" (or "squash") are supported.
+// Fields with the "required" option, or tagged with `reflector:"required"`,
+// must be present when loading maps in strict mode, see MapOptions.Strict.
+func (r *StructReflector) ToTaggedMap(tag string, omitZero, omitEmpty bool) map[string]interface{} {
+	return r.ToMapWith(&MapOptions{
+		Tag:  tag,
+		Zero: zeroPolicy(omitZero, omitEmpty),
+	})
+}
+
+// ToMapWith recursively converts the struct to a map, using the given options.
+// Map keys are converted to strings. []byte and time.Time values, and
+// structs implementing encoding.TextMarshaler or json.Marshaler, are kept
+// as they are. Structs whose marshal methods have a pointer receiver, like
+// big.Int, are stored as pointers.
+// Pointers, maps and slices that reference a value which is already being
+// converted, like in self-referencing pointer graphs, are stored as nil.
+func (r *StructReflector) ToMapWith(opts *MapOptions) map[string]interface{} {
+	if opts == nil {
+		opts = &MapOptions{}
+	}
+	e := newEncoder(opts)
+	if r.isPtr {
+		e.enter(r.item.Value())
+	}
+	return r.toMap(e, 1)
+}
+
+func (r *StructReflector) toMap(e *encoder, depth int) map[string]interface{} {
+	opts := e.opts
 	data := make(map[string]interface{})
-	for name, field := range r.Fields() {
-		if (field.IsStruct() || field.IsStructPtr()) && !field.IsZero() {
-			s, _ := newStructReflector(field)
-			d := s.ToMap(omitZero, omitEmpty)
-
-			// Add embedded fields to the main data.
-			if f, _ := r.Type().FieldByName(name); f.Anonymous {
-				for key, val := range d {
-					data[key] = val
-				}
-			} else {
-				data[name] = d
+	for _, field := range r.mapFields(opts) {
+		val := field.value
+
+		// Add inlined fields to the main data.
+		if field.inline {
+			if val.IsNil() {
+				continue
+			}
+			if val.IsPtr() && !e.enter(val.Value()) {
+				continue
+			}
+			s, _ := newStructReflector(val)
+			for key, item := range s.toMap(e, depth) {
+				data[key] = item
+			}
+			if val.IsPtr() {
+				e.leave(val.Value())
 			}
 			continue
 		}
 
-		if omitEmpty && field.IsEmpty() {
+		if (opts.Zero == EmptyOmit || field.omitEmpty) && val.IsEmpty() {
 			continue
 		}
-		if field.IsZero() {
-			if omitZero {
+		if val.IsZero() {
+			switch opts.Zero {
+			case ZeroOmit, EmptyOmit:
 				continue
-			} else {
-				data[name] = nil
+			case ZeroNil:
+				data[field.name] = nil
 				continue
 			}
 		}
-		data[name] = field.Interface()
+		data[field.name] = mapValue(val, field, e, depth)
 	}
 	return data
 }
 
+// mapValue returns the value stored in a map for the given field.
+func mapValue(val *Reflector, field *structField, e *encoder, depth int) interface{} {
+	if field.asString {
+		if str, err := val.ConvertToTypeWith(stringType, &e.opts.ConvertOptions); err == nil {
+			return str
+		}
+	}
+	return e.encode(val.Value(), depth)
+}
+
+// FromMap loads field values from a map, keyed by field names.
+// Nested maps are loaded into nested structs.
+// If convert is true, values are converted to the field types.
 func (r *StructReflector) FromMap(data map[string]interface{}, convert ...bool) error {
-	for key, rawVal := range data {
-		field := r.Field(key)
-		if field == nil {
+	return r.FromMapWith(data, &MapOptions{
+		ConvertOptions: ConvertOptions{Mode: convertMode(convert)},
+	})
+}
+
+// FromTaggedMap works like FromMap, but reads map keys from the given
+// struct tag. See ToTaggedMap.
+// Fields with the "string" option are always converted from string values.
+func (r *StructReflector) FromTaggedMap(tag string, data map[string]interface{}, convert ...bool) error {
+	return r.FromMapWith(data, &MapOptions{
+		Tag:            tag,
+		ConvertOptions: ConvertOptions{Mode: convertMode(convert)},
+	})
+}
+
+// FromMapWith loads field values from a map, using the given options.
+func (r *StructReflector) FromMapWith(data map[string]interface{}, opts *MapOptions) error {
+	if opts == nil {
+		opts = &MapOptions{}
+	}
+	return r.fromMap(data, opts, 1)
+}
+
+func (r *StructReflector) fromMap(data map[string]interface{}, opts *MapOptions, depth int) error {
+	errs := &errorList{collect: opts.Strict}
+	used := make(map[string]bool)
+	if err := r.loadMap(data, opts, depth, used, errs); err != nil {
+		return err
+	}
+	if err := errs.add(checkUnknownKeys(data, used, opts)); err != nil {
+		return err
+	}
+	return errs.err()
+}
+
+// loadMap loads data into the struct fields and marks used keys.
+func (r *StructReflector) loadMap(data map[string]interface{}, opts *MapOptions, depth int, used map[string]bool, errs *errorList) error {
+	for _, field := range r.mapFields(opts) {
+		if field.inline {
+			if err := r.inlineFromMap(field, data, opts, depth, used, errs); err != nil {
+				return err
+			}
 			continue
 		}
 
-		val := Reflect(rawVal)
-		if val == nil || val.IsZero() {
+		if field.info.PkgPath != "" {
+			// Unexported fields can not be set.
+			used[field.name] = true
 			continue
 		}
 
-		// Handle nested structs.
-		if nestedMap, ok := rawVal.(map[string]interface{}); ok && field.IsStruct() || field.IsStructPtr() {
-			// Obtain StructReflector.
-			nestedStruct, err := field.Struct()
-			if err != nil {
-				return err
+		rawVal, ok := data[field.name]
+		if (!ok || rawVal == nil) && opts.Strict {
+			if field.required {
+				errs.add(&Error{Code: ERR_MISSING_FIELD, Path: field.name})
+			} else if isStructValue(field.value) {
+				// Required fields of missing nested structs are missing too.
+				err := missingFields(field.value.Type(), opts, make(map[reflect.Type]bool))
+				errs.add(prefixPath(err, field.name))
 			}
-			// run FromMap on nested struct.
-			if err := nestedStruct.FromMap(nestedMap, convert...); err != nil {
+		}
+		if !ok {
+			continue
+		}
+		used[field.name] = true
+
+		val := Reflect(rawVal)
+		if val.IsZero() && opts.Zero != ZeroKeep {
+			continue
+		}
+
+		var err error
+		if field.asString && val.IsString() {
+			// Fields with the "string" option are always converted.
+			err = assignValue(field.value.Value(), val, opts.ConvertOptions.lenient())
+		} else {
+			// Nested structs, slices, maps and pointers are decoded recursively.
+			err = decodeValue(field.value.Value(), val, opts, depth)
+		}
+		if err != nil {
+			if err := errs.add(prefixPath(err, field.name)); err != nil {
 				return err
 			}
+		}
+	}
+	return nil
+}
 
-			// nested fromMap succeeded
-			continue
+// missingFields returns an error for each required field of the struct type
+// typ, or the struct it points to, including the required fields of nested
+// structs.
+func missingFields(typ reflect.Type, opts *MapOptions, active map[reflect.Type]bool) error {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	// Stop at recursive types.
+	if active[typ] {
+		return nil
+	}
+	active[typ] = true
+	defer delete(active, typ)
+
+	errs := &errorList{collect: true}
+	for _, field := range New(typ).MustStruct().mapFields(opts) {
+		switch {
+		case field.info.PkgPath != "" && !field.inline:
+		case field.required:
+			errs.add(&Error{Code: ERR_MISSING_FIELD, Path: field.name})
+		case field.inline:
+			errs.add(missingFields(field.value.Type(), opts, active))
+		case isStructValue(field.value):
+			errs.add(prefixPath(missingFields(field.value.Type(), opts, active), field.name))
+		}
+	}
+	return errs.err()
+}
+
+// inlineFromMap loads data into an inlined struct field.
+// Nil struct pointers are only allocated if any value was loaded.
+func (r *StructReflector) inlineFromMap(field *structField, data map[string]interface{}, opts *MapOptions, depth int, used map[string]bool, errs *errorList) error {
+	val := field.value
+	if !val.IsStructPtr() || !val.IsNil() {
+		nested, err := val.Struct()
+		if err != nil {
+			return errs.add(prefixPath(err, field.info.Name))
+		}
+		return nested.loadMap(data, opts, depth, used, errs)
+	}
+
+	nested := New(val.Type().Elem()).MustStruct()
+	if err := nested.loadMap(data, opts, depth, used, errs); err != nil {
+		return err
+	}
+	if nested.structItem.IsZero() {
+		return nil
+	}
+	return errs.add(prefixPath(val.Set(nested.Value()), field.info.Name))
+}
+
+// checkUnknownKeys returns an error for keys in data that were not
+// used, if opts.ErrorOnUnknown or opts.Strict is set.
+// In strict mode, all unknown keys are reported.
+func checkUnknownKeys(data map[string]interface{}, used map[string]bool, opts *MapOptions) error {
+	if !(opts.ErrorOnUnknown || opts.Strict) {
+		return nil
+	}
+	unknown := make([]string, 0)
+	for key := range data {
+		if !used[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+
+	if !opts.Strict {
+		return &Error{Code: ERR_UNKNOWN_FIELD, Path: unknown[0]}
+	}
+	errs := &MultiError{}
+	for _, key := range unknown {
+		errs.Errors = append(errs.Errors, &Error{Code: ERR_UNKNOWN_FIELD, Path: key})
+	}
+	return errs
+}
+
+// FieldByTag returns the field whose name in the given struct tag equals name,
+// or nil if no such field exists.
+// Fields of inlined structs are searched as well.
+func (r *StructReflector) FieldByTag(tag, name string) *Reflector {
+	fields := r.mapFields(&MapOptions{Tag: tag})
+	for _, field := range fields {
+		if !field.inline && field.name == name {
+			return field.value
 		}
+	}
 
-		// Handle regular values.
-		if err := field.Set(val, convert...); err != nil {
-			return errors.New("Error in field " + key + ": " + err.Error())
+	for _, field := range fields {
+		if !field.inline || (field.value.IsPtr() && field.value.IsNil()) {
+			continue
+		}
+		nested, err := field.value.Struct()
+		if err != nil {
+			continue
+		}
+		if f := nested.FieldByTag(tag, name); f != nil {
+			return f
 		}
 	}
 	return nil
 }
+
+// packageTag is the struct tag that holds options specific to this package,
+// like `reflector:"required"`.
+const packageTag = "reflector"
+
+// hasPackageOption returns true if the package tag of the field contains
+// the given option.
+func hasPackageOption(info reflect.StructField, option string) bool {
+	for _, o := range strings.Split(info.Tag.Get(packageTag), ",") {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// structField describes how a struct field is represented in a map.
+type structField struct {
+	info  reflect.StructField
+	value *Reflector
+
+	name      string
+	omitEmpty bool
+	asString  bool
+	inline    bool
+	required  bool
+}
+
+// mapFields returns all fields in declaration order, with names and
+// options read from the struct tag configured in opts.
+// Fields tagged with "-" are skipped, as are unexported fields unless
+// opts.IncludeUnexported is set. Unexported fields hold a read-only copy.
+func (r *StructReflector) mapFields(opts *MapOptions) []*structField {
+	typ := r.Type()
+	fields := make([]*structField, 0, typ.NumField())
+
+	structValue := r.structItem.Value()
+	for i := 0; i < typ.NumField(); i++ {
+		info := typ.Field(i)
+
+		value := structValue.Field(i)
+		if info.PkgPath != "" && !info.Anonymous && !opts.IncludeUnexported {
+			continue
+		}
+
+		// Not using Reflect(), which would de-reference interfaces.
+		field := &structField{
+			info:  info,
+			value: &Reflector{value: value},
+			name:  info.Name,
+		}
+
+		tagValue := ""
+		if opts.Tag != "" {
+			tagValue = info.Tag.Get(opts.Tag)
+		}
+		if tagValue == "-" {
+			continue
+		}
+
+		parts := strings.Split(tagValue, ",")
+		if parts[0] != "" {
+			field.name = parts[0]
+		} else if opts.Naming != nil {
+			field.name = opts.Naming(info.Name)
+		}
+		for _, option := range parts[1:] {
+			switch option {
+			case "omitempty":
+				field.omitEmpty = true
+			case "string":
+				field.asString = true
+			case "inline", "squash":
+				field.inline = true
+			case "required":
+				field.required = true
+			}
+		}
+		if hasPackageOption(info, "required") {
+			field.required = true
+		}
+
+		// Embedded structs without an explicit name are inlined.
+		if info.Anonymous && parts[0] == "" {
+			field.inline = true
+		}
+		if field.inline && !isStructValue(field.value) {
+			field.inline = false
+		}
+
+		if info.PkgPath != "" && !field.inline {
+			if !opts.IncludeUnexported {
+				continue
+			}
+			// Unexported fields can only be read.
+			field.value = &Reflector{value: readable(value)}
+		}
+
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// readable returns v, or a copy of v that can be used with Interface if v
+// was obtained through unexported struct fields.
+// Unexported fields can not be set, so structs in the copy only contain
+// their exported fields. Functions, channels and unsafe pointers are
+// copied as nil.
+func readable(v reflect.Value) reflect.Value {
+	if !v.IsValid() || v.CanInterface() {
+		return v
+	}
+	// Return a value that is not settable, like the original.
+	return reflect.ValueOf(readableCopy(v, make(map[copyKey]reflect.Value)).Interface())
+}
+
+func readableCopy(v reflect.Value, copies map[copyKey]reflect.Value) reflect.Value {
+	typ := v.Type()
+	cp := reflect.New(typ).Elem()
+
+	switch v.Kind() {
+	case reflect.Bool:
+		cp.SetBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		cp.SetInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		cp.SetUint(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		cp.SetFloat(v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		cp.SetComplex(v.Complex())
+	case reflect.String:
+		cp.SetString(v.String())
+
+	case reflect.Ptr:
+		if v.IsNil() {
+			break
+		}
+		key := copyKey{ptr: v.Pointer(), typ: typ}
+		if ptr, ok := copies[key]; ok {
+			return ptr
+		}
+		ptr := reflect.New(typ.Elem())
+		copies[key] = ptr
+		ptr.Elem().Set(readableCopy(v.Elem(), copies))
+		cp.Set(ptr)
+
+	case reflect.Interface:
+		if !v.IsNil() {
+			cp.Set(readableCopy(v.Elem(), copies))
+		}
+
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if field := cp.Field(i); field.CanSet() {
+				field.Set(readableCopy(v.Field(i), copies))
+			}
+		}
+
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			cp.Index(i).Set(readableCopy(v.Index(i), copies))
+		}
+
+	case reflect.Slice:
+		if v.IsNil() {
+			break
+		}
+		cp.Set(reflect.MakeSlice(typ, v.Len(), v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			cp.Index(i).Set(readableCopy(v.Index(i), copies))
+		}
+
+	case reflect.Map:
+		if v.IsNil() {
+			break
+		}
+		cp.Set(reflect.MakeMapWithSize(typ, v.Len()))
+		iter := v.MapRange()
+		for iter.Next() {
+			cp.SetMapIndex(readableCopy(iter.Key(), copies), readableCopy(iter.Value(), copies))
+		}
+	}
+	return cp
+}
+
+// isStructValue returns true for structs and struct pointers that are
+// treated as nested structures, which excludes time.Time.
+func isStructValue(r *Reflector) bool {
+	if !(r.IsStruct() || r.IsStructPtr()) {
+		return false
+	}
+	return !isTimeValue(r)
+}
+
+func isTimeValue(r *Reflector) bool {
+	if !r.IsValid() {
+		return false
+	}
+	typ := r.Type()
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ == timeType
+}
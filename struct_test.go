@@ -1,6 +1,7 @@
 package reflector_test
 
 import (
+	"errors"
 	"reflect"
 
 	. "github.com/theduke/go-reflector"
@@ -9,6 +10,26 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+type tagInner struct {
+	City string `json:"city"`
+}
+
+type TagEmbedded struct {
+	Level int `json:"level"`
+}
+
+type tagStruct struct {
+	TagEmbedded
+	Name     string    `json:"name"`
+	Secret   string    `json:"-"`
+	Count    int       `json:"count,omitempty"`
+	ID       int64     `json:"id,string"`
+	Inner    tagInner  `json:"inner"`
+	Squashed tagInner  `json:",squash"`
+	Ptr      *tagInner `json:"ptr,omitempty"`
+	Plain    string
+}
+
 var _ = Describe("Struct", func() {
 	It("Should return error on Struct() with non-struct", func() {
 		_, err := Reflect(22).Struct()
@@ -298,4 +319,157 @@ var _ = Describe("Struct", func() {
 			Expect(*s).To(Equal(cs))
 		})
 	})
+
+	Describe("Struct tags", func() {
+		It("Should find fields with .FieldByTag()", func() {
+			s := &tagStruct{Name: "n", TagEmbedded: TagEmbedded{Level: 3}}
+			r := Reflect(s).MustStruct()
+
+			Expect(r.FieldByTag("json", "name").Interface()).To(Equal("n"))
+			Expect(r.FieldByTag("json", "level").Interface()).To(Equal(3))
+			Expect(r.FieldByTag("json", "Plain")).ToNot(BeNil())
+			Expect(r.FieldByTag("json", "Secret")).To(BeNil())
+			Expect(r.FieldByTag("json", "Name")).To(BeNil())
+		})
+
+		It("Should convert to map with tag names", func() {
+			s := tagStruct{
+				TagEmbedded: TagEmbedded{Level: 3},
+				Name:        "n",
+				Secret:      "s",
+				ID:          22,
+				Inner:       tagInner{City: "a"},
+				Squashed:    tagInner{City: "b"},
+				Plain:       "p",
+			}
+
+			data := Reflect(s).MustStruct().ToTaggedMap("json", false, false)
+			Expect(data).To(Equal(map[string]interface{}{
+				"level": 3,
+				"name":  "n",
+				"id":    "22",
+				"inner": map[string]interface{}{"city": "a"},
+				"city":  "b",
+				"Plain": "p",
+			}))
+		})
+
+		It("Should load data from map with tag names", func() {
+			data := map[string]interface{}{
+				"level": 3,
+				"name":  "n",
+				"Name":  "wrong",
+				"count": 5,
+				"id":    "22",
+				"inner": map[string]interface{}{"city": "a"},
+				"city":  "b",
+				"ptr":   map[string]interface{}{"city": "c"},
+			}
+
+			s := &tagStruct{}
+			err := Reflect(s).MustStruct().FromTaggedMap("json", data)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*s).To(Equal(tagStruct{
+				TagEmbedded: TagEmbedded{Level: 3},
+				Name:        "n",
+				Count:       5,
+				ID:          22,
+				Inner:       tagInner{City: "a"},
+				Squashed:    tagInner{City: "b"},
+				Ptr:         &tagInner{City: "c"},
+			}))
+		})
+
+		It("Should report tag names in FromTaggedMap errors", func() {
+			data := map[string]interface{}{
+				"inner": map[string]interface{}{"city": 1},
+			}
+			err := Reflect(&tagStruct{}).MustStruct().FromTaggedMap("json", data)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(*Error).Path).To(Equal("inner.city"))
+		})
+	})
+
+	Describe("Strict FromMapWith", func() {
+		type strictInner struct {
+			Port int `json:"port" reflector:"required"`
+		}
+		type strictConfig struct {
+			Name  string      `json:"name,required"`
+			Count int         `json:"count"`
+			Inner strictInner `json:"inner"`
+		}
+
+		It("Should report all problems at once", func() {
+			data := map[string]interface{}{
+				"count":   "x",
+				"unknown": 1,
+				"other":   2,
+				"inner": map[string]interface{}{
+					"typo": 1,
+				},
+			}
+
+			s := &strictConfig{}
+			err := Reflect(s).MustStruct().FromMapWith(data, &MapOptions{
+				Tag:            "json",
+				Strict:         true,
+				ConvertOptions: ConvertOptions{Mode: ConvertLenient},
+			})
+			Expect(err).To(HaveOccurred())
+
+			multi, ok := err.(*MultiError)
+			Expect(ok).To(BeTrue())
+
+			paths := []string{}
+			for _, e := range multi.Errors {
+				paths = append(paths, e.Code+":"+e.Path)
+			}
+			Expect(paths).To(Equal([]string{
+				ERR_MISSING_FIELD + ":name",
+				ERR_UNCONVERTABLE_TYPES + ":count",
+				ERR_MISSING_FIELD + ":inner.port",
+				ERR_UNKNOWN_FIELD + ":inner.typo",
+				ERR_UNKNOWN_FIELD + ":other",
+				ERR_UNKNOWN_FIELD + ":unknown",
+			}))
+
+			Expect(errors.Is(err, ErrMissingField)).To(BeTrue())
+			Expect(errors.Is(err, ErrUnknownField)).To(BeTrue())
+			Expect(err.Error()).To(HavePrefix("6 errors: "))
+		})
+
+		It("Should succeed with valid data", func() {
+			data := map[string]interface{}{
+				"name":  "n",
+				"inner": map[string]interface{}{"port": 80},
+			}
+			s := &strictConfig{}
+			err := Reflect(s).MustStruct().FromMapWith(data, &MapOptions{Tag: "json", Strict: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(s.Inner.Port).To(Equal(80))
+		})
+
+		It("Should check required fields of missing nested structs", func() {
+			type strictNested struct {
+				Config *strictConfig `json:"config"`
+			}
+			err := Reflect(&strictNested{}).MustStruct().FromMapWith(map[string]interface{}{
+				"config": map[string]interface{}{"name": "n"},
+			}, &MapOptions{Tag: "json", Strict: true})
+			Expect(errors.Is(err, ErrMissingField)).To(BeTrue())
+			Expect(err.(*MultiError).Errors[0].Path).To(Equal("config.inner.port"))
+
+			err = Reflect(&strictNested{}).MustStruct().FromMapWith(map[string]interface{}{}, &MapOptions{Tag: "json", Strict: true})
+			Expect(err).To(HaveOccurred())
+			Expect(err.(*MultiError).Errors).To(HaveLen(2))
+			Expect(err.(*MultiError).Errors[0].Path).To(Equal("config.name"))
+			Expect(err.(*MultiError).Errors[1].Path).To(Equal("config.inner.port"))
+		})
+
+		It("Should ignore required fields when not strict", func() {
+			err := Reflect(&strictConfig{}).MustStruct().FromMapWith(map[string]interface{}{}, &MapOptions{Tag: "json"})
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
 })
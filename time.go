@@ -0,0 +1,161 @@
+package reflector
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// DefaultTimeLayouts are the layouts used to parse strings into time.Time
+// if ConvertOptions.TimeLayouts is empty.
+var DefaultTimeLayouts = []string{time.RFC3339}
+
+// Commonly used layouts that are not defined by the time package.
+const (
+	DateLayout     = "2006-01-02"
+	DateTimeLayout = "2006-01-02 15:04:05"
+)
+
+// UnixPrecision is the unit of numeric Unix timestamps.
+type UnixPrecision int
+
+const (
+	// UnixSeconds treats numbers as seconds since the Unix epoch.
+	// Fractions of floats are used as sub-second precision.
+	UnixSeconds UnixPrecision = iota
+	UnixMillis
+	UnixMicros
+	UnixNanos
+)
+
+func (p UnixPrecision) unit() time.Duration {
+	switch p {
+	case UnixMillis:
+		return time.Millisecond
+	case UnixMicros:
+		return time.Microsecond
+	case UnixNanos:
+		return time.Nanosecond
+	}
+	return time.Second
+}
+
+func (o *ConvertOptions) timeLayouts() []string {
+	if len(o.TimeLayouts) > 0 {
+		return o.TimeLayouts
+	}
+	return DefaultTimeLayouts
+}
+
+func (o *ConvertOptions) timeLocation() *time.Location {
+	if o.TimeLocation != nil {
+		return o.TimeLocation
+	}
+	return time.UTC
+}
+
+// parseTime parses str with the first matching layout.
+func parseTime(str string, opts *ConvertOptions) (time.Time, error) {
+	var err error
+	for _, layout := range opts.timeLayouts() {
+		var t time.Time
+		if t, err = time.ParseInLocation(layout, str, opts.timeLocation()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, &Error{
+		Code:    ERR_INVALID_TIME,
+		Source:  stringType,
+		Target:  timeType,
+		Message: "expected one of the layouts " + strings.Join(opts.timeLayouts(), ", "),
+		Err:     err,
+	}
+}
+
+// unixTime converts a numeric Unix timestamp to time.Time.
+func unixTime(r *Reflector, opts *ConvertOptions) time.Time {
+	unit := opts.UnixPrecision.unit()
+
+	var nanos int64
+	switch r.Kind() {
+	case reflect.Float32, reflect.Float64:
+		whole, frac := math.Modf(r.value.Float())
+		nanos = int64(whole)*int64(unit) + int64(frac*float64(unit))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		nanos = int64(r.value.Uint()) * int64(unit)
+	default:
+		nanos = r.value.Int() * int64(unit)
+	}
+	return time.Unix(0, nanos).In(opts.timeLocation())
+}
+
+// parseDuration parses strings like "1h30m", or numbers of nanoseconds.
+func parseDuration(str string) (time.Duration, error) {
+	str = strings.TrimSpace(str)
+	d, err := time.ParseDuration(str)
+	if err == nil {
+		return d, nil
+	}
+	if num, numErr := strconv.ParseFloat(str, 64); numErr == nil {
+		return time.Duration(num), nil
+	}
+	return 0, &Error{
+		Code:   ERR_UNCONVERTABLE_TYPES,
+		Source: stringType,
+		Target: durationType,
+		Err:    err,
+	}
+}
+
+// convertTime handles conversions from strings and numbers to time.Time,
+// from time.Time to numbers, and from strings to time.Duration.
+// Pointer targets are supported.
+// ok is false if none of these conversions applies.
+func convertTime(r *Reflector, typ reflect.Type, opts *ConvertOptions) (result interface{}, ok bool, err error) {
+	target := typ
+	if target.Kind() == reflect.Ptr {
+		target = target.Elem()
+	}
+	source := r.Type()
+
+	switch {
+	case target == timeType && r.IsString():
+		result, err = parseTime(r.value.String(), opts)
+
+	case target == timeType && r.IsNumeric() && source != durationType:
+		result = unixTime(r, opts)
+
+	case target == durationType && r.IsString():
+		result, err = parseDuration(r.value.String())
+
+	case source == timeType && IsNumericKind(target.Kind()) && target != durationType:
+		t := r.value.Interface().(time.Time)
+		unix := reflect.ValueOf(t.UnixNano() / int64(opts.UnixPrecision.unit()))
+		if opts.UnixPrecision == UnixSeconds {
+			unix = reflect.ValueOf(t.Unix())
+		}
+		result = unix.Convert(target).Interface()
+
+	default:
+		return nil, false, nil
+	}
+
+	if err != nil {
+		if e, ok := err.(*Error); ok {
+			e.Source = source
+			e.Target = typ
+		}
+		return nil, true, err
+	}
+
+	if typ.Kind() == reflect.Ptr {
+		ptr := reflect.New(target)
+		ptr.Elem().Set(reflect.ValueOf(result).Convert(target))
+		return ptr.Interface(), true, nil
+	}
+	return reflect.ValueOf(result).Convert(target).Interface(), true, nil
+}
@@ -0,0 +1,101 @@
+package reflector_test
+
+import (
+	"errors"
+	"reflect"
+	"time"
+
+	. "github.com/theduke/go-reflector"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type timeConfig struct {
+	Started  time.Time
+	Expires  *time.Time
+	Timeout  time.Duration
+	Interval *time.Duration
+}
+
+var _ = Describe("Time conversion", func() {
+	timeTyp := reflect.TypeOf(time.Time{})
+	date := time.Date(2015, 11, 4, 10, 30, 0, 0, time.UTC)
+
+	It("Should parse RFC3339 by default", func() {
+		Expect(R("2015-11-04T10:30:00Z").ConvertTo(time.Time{})).To(Equal(date))
+
+		_, err := R("2015-11-04").ConvertTo(time.Time{})
+		Expect(errors.Is(err, ErrInvalidTime)).To(BeTrue())
+	})
+
+	It("Should parse with the configured layouts", func() {
+		opts := &ConvertOptions{
+			TimeLayouts: []string{time.RFC3339Nano, time.RFC1123, DateTimeLayout, DateLayout},
+		}
+
+		Expect(R("2015-11-04").ConvertToTypeWith(timeTyp, opts)).To(Equal(time.Date(2015, 11, 4, 0, 0, 0, 0, time.UTC)))
+		Expect(R("2015-11-04 10:30:00").ConvertToTypeWith(timeTyp, opts)).To(Equal(date))
+		Expect(R("2015-11-04T10:30:00.5Z").ConvertToTypeWith(timeTyp, opts)).To(Equal(date.Add(500 * time.Millisecond)))
+
+		t, err := R("Wed, 04 Nov 2015 10:30:00 UTC").ConvertToTypeWith(timeTyp, opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(t.(time.Time).Equal(date)).To(BeTrue())
+
+		_, err = R("yesterday").ConvertToTypeWith(timeTyp, opts)
+		Expect(errors.Is(err, ErrInvalidTime)).To(BeTrue())
+	})
+
+	It("Should parse in the configured location", func() {
+		loc := time.FixedZone("test", 3600)
+		t, err := R("2015-11-04").ConvertToTypeWith(timeTyp, &ConvertOptions{
+			TimeLayouts:  []string{DateLayout},
+			TimeLocation: loc,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(t).To(Equal(time.Date(2015, 11, 4, 0, 0, 0, 0, loc)))
+	})
+
+	It("Should convert Unix timestamps", func() {
+		Expect(R(date.Unix()).ConvertTo(time.Time{})).To(Equal(date))
+		Expect(R(float64(date.Unix()) + 0.25).ConvertTo(time.Time{})).To(Equal(date.Add(250 * time.Millisecond)))
+
+		millis := &ConvertOptions{UnixPrecision: UnixMillis}
+		Expect(R(date.UnixNano()/1e6).ConvertToTypeWith(timeTyp, millis)).To(Equal(date))
+		Expect(R(date).ConvertToTypeWith(reflect.TypeOf(int64(0)), millis)).To(Equal(date.UnixNano() / 1e6))
+		Expect(R(date).ConvertTo(int64(0))).To(Equal(date.Unix()))
+	})
+
+	It("Should format times with a layout", func() {
+		Expect(R(date).ConvertToTypeWith(reflect.TypeOf(""), &ConvertOptions{TimeFormat: DateLayout})).To(Equal("2015-11-04"))
+	})
+
+	It("Should convert durations", func() {
+		Expect(R("1h30m").ConvertTo(time.Duration(0))).To(Equal(90 * time.Minute))
+		Expect(R("1000").ConvertTo(time.Duration(0))).To(Equal(time.Microsecond))
+		Expect(R(int64(1000)).ConvertTo(time.Duration(0))).To(Equal(time.Microsecond))
+		Expect(R(90 * time.Minute).ConvertTo("")).To(Equal("1h30m0s"))
+		Expect(R(time.Second).ConvertTo(int64(0))).To(Equal(int64(1e9)))
+
+		_, err := R("soon").ConvertTo(time.Duration(0))
+		Expect(errors.Is(err, ErrUnconvertableTypes)).To(BeTrue())
+	})
+
+	It("Should load config values with FromMap", func() {
+		c := &timeConfig{}
+		err := R(c).MustStruct().FromMapWith(map[string]interface{}{
+			"Started":  "2015-11-04",
+			"Expires":  float64(date.Unix()),
+			"Timeout":  "30s",
+			"Interval": "5m",
+		}, &MapOptions{ConvertOptions: ConvertOptions{
+			Mode:        ConvertLenient,
+			TimeLayouts: []string{time.RFC3339, DateLayout},
+		}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.Started).To(Equal(time.Date(2015, 11, 4, 0, 0, 0, 0, time.UTC)))
+		Expect(*c.Expires).To(Equal(date))
+		Expect(c.Timeout).To(Equal(30 * time.Second))
+		Expect(*c.Interval).To(Equal(5 * time.Minute))
+	})
+})
@@ -0,0 +1,352 @@
+package reflector
+
+import (
+	"net/mail"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ValidatorFunc checks value against a validation rule.
+// param holds the rule parameter, like "3" for "min=3", and parent is the
+// struct that contains the field, for rules that compare fields.
+// Pointers are de-referenced before validators are called, nil pointers are
+// only checked by the "required" rule.
+// An error means the rule itself is invalid, like an unparsable parameter.
+type ValidatorFunc func(value *Reflector, param string, parent *StructReflector) (bool, error)
+
+// ValidatorRegistry holds validators by name.
+// A ValidatorRegistry is safe for concurrent use.
+type ValidatorRegistry struct {
+	mu         sync.RWMutex
+	validators map[string]ValidatorFunc
+}
+
+// DefaultValidators is the global registry of custom validators.
+// Registries passed with ValidateOptions are consulted first, and the
+// builtin validators last.
+var DefaultValidators = NewValidatorRegistry()
+
+func NewValidatorRegistry() *ValidatorRegistry {
+	return &ValidatorRegistry{
+		validators: make(map[string]ValidatorFunc),
+	}
+}
+
+// Register registers a validator for the given rule name.
+// Registering a nil function removes the validator.
+func (v *ValidatorRegistry) Register(name string, f ValidatorFunc) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if f == nil {
+		delete(v.validators, name)
+	} else {
+		v.validators[name] = f
+	}
+}
+
+// Lookup returns the validator for the given rule name, or nil.
+func (v *ValidatorRegistry) Lookup(name string) ValidatorFunc {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.validators[name]
+}
+
+// ValidateOptions control ValidateWith.
+// A nil *ValidateOptions is equivalent to the zero value.
+type ValidateOptions struct {
+	// Tag is the struct tag to read rules from. Defaults to "validate".
+	Tag string
+
+	// Validators are consulted before DefaultValidators.
+	Validators *ValidatorRegistry
+}
+
+var builtinValidators = map[string]ValidatorFunc{
+	"required": func(value *Reflector, _ string, _ *StructReflector) (bool, error) {
+		return !value.IsEmpty(), nil
+	},
+	"len":   compareSize("="),
+	"min":   compareSize(">="),
+	"max":   compareSize("<="),
+	"gt":    compareSize(">"),
+	"gte":   compareSize(">="),
+	"lt":    compareSize("<"),
+	"lte":   compareSize("<="),
+	"eq":    compareParam("="),
+	"ne":    compareParam("!="),
+	"oneof": oneOf,
+	"email": isEmail,
+	"regex": matchRegex,
+
+	"eqfield":  compareField("="),
+	"nefield":  compareField("!="),
+	"gtfield":  compareField(">"),
+	"gtefield": compareField(">="),
+	"ltfield":  compareField("<"),
+	"ltefield": compareField("<="),
+}
+
+// size returns the length of strings, slices, arrays and maps, and the
+// value itself for all other values.
+func size(value *Reflector) *Reflector {
+	switch value.Kind() {
+	case reflect.String:
+		return Reflect(utf8.RuneCountInString(value.value.String()))
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Chan:
+		return Reflect(value.Len())
+	}
+	return value
+}
+
+// compareSize compares the size of a value with the parameter.
+func compareSize(operator string) ValidatorFunc {
+	return func(value *Reflector, param string, _ *StructReflector) (bool, error) {
+		value = size(value)
+		limit, err := Reflect(param).ConvertToType(value.Type())
+		if err != nil {
+			return false, err
+		}
+		if result, ok, err := compareNumbers(value, Reflect(limit), operator); ok {
+			return result, err
+		}
+		return value.CompareTo(limit, operator)
+	}
+}
+
+// compareNumbers compares a with b as float64 values.
+// ok is false if a or b is not a number.
+func compareNumbers(a, b *Reflector, operator string) (result, ok bool, err error) {
+	if !a.IsNumeric() || !b.IsNumeric() {
+		return false, false, nil
+	}
+	x, err := a.ConvertTo(float64(0))
+	if err != nil {
+		return false, true, err
+	}
+	y, err := b.ConvertTo(float64(0))
+	if err != nil {
+		return false, true, err
+	}
+	result, err = compareFloat64Values(operator, x.(float64), y.(float64))
+	return result, true, err
+}
+
+// compareParam compares a value with the parameter.
+func compareParam(operator string) ValidatorFunc {
+	return func(value *Reflector, param string, _ *StructReflector) (bool, error) {
+		return value.CompareTo(param, operator)
+	}
+}
+
+func oneOf(value *Reflector, param string, _ *StructReflector) (bool, error) {
+	for _, option := range strings.Fields(param) {
+		ok, err := value.CompareTo(option, "=")
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func isEmail(value *Reflector, _ string, _ *StructReflector) (bool, error) {
+	if !value.IsString() {
+		return false, &Error{Code: ERR_TYPE_MISMATCH, Source: value.Type(), Target: stringType}
+	}
+	str := value.value.String()
+	address, err := mail.ParseAddress(str)
+	return err == nil && address.Address == str, nil
+}
+
+func matchRegex(value *Reflector, param string, _ *StructReflector) (bool, error) {
+	re, err := compileRegex(param)
+	if err != nil {
+		return false, err
+	}
+
+	str, err := value.ConvertTo("")
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(str.(string)), nil
+}
+
+// compareField compares a value with the field of the parent struct
+// named by the parameter.
+func compareField(operator string) ValidatorFunc {
+	return func(value *Reflector, param string, parent *StructReflector) (bool, error) {
+		if parent == nil || !parent.HasField(param) {
+			return false, &Error{Code: ERR_UNKNOWN_FIELD, Path: param}
+		}
+		field := parent.Field(param)
+		if result, ok, err := compareNumbers(value, field, operator); ok {
+			return result, err
+		}
+		return value.CompareTo(field, operator)
+	}
+}
+
+type validationRule struct {
+	name  string
+	param string
+}
+
+// parseRules parses a tag like "required,min=3,oneof=a b".
+// Regular expressions may contain commas, so "regex" must be the last rule.
+func parseRules(tag string) []validationRule {
+	rules := make([]validationRule, 0)
+	for tag != "" {
+		rule := tag
+		tag = ""
+		if i := strings.IndexByte(rule, ','); i >= 0 && !strings.HasPrefix(rule, "regex=") {
+			rule, tag = rule[:i], rule[i+1:]
+		}
+
+		name, param := rule, ""
+		if i := strings.IndexByte(rule, '='); i >= 0 {
+			name, param = rule[:i], rule[i+1:]
+		}
+		if name = strings.TrimSpace(name); name != "" {
+			rules = append(rules, validationRule{name, param})
+		}
+	}
+	return rules
+}
+
+type validator struct {
+	opts *ValidateOptions
+	errs *errorList
+	// dive holds the rules for the items of collection nodes.
+	dive map[*WalkNode][]validationRule
+}
+
+func (v *validator) lookup(name string) ValidatorFunc {
+	if v.opts.Validators != nil {
+		if f := v.opts.Validators.Lookup(name); f != nil {
+			return f
+		}
+	}
+	if f := DefaultValidators.Lookup(name); f != nil {
+		return f
+	}
+	return builtinValidators[name]
+}
+
+func (v *validator) visit(node *WalkNode) error {
+	var rules []validationRule
+	switch {
+	case node.Field != nil:
+		tag := node.Field.Tag.Get(v.opts.Tag)
+		if tag == "-" {
+			return ErrSkipNode
+		}
+		rules = parseRules(tag)
+	case node.Parent != nil:
+		rules = v.dive[node.Parent]
+	}
+
+	for i, rule := range rules {
+		if rule.name == "dive" {
+			v.dive[node] = rules[i+1:]
+			rules = rules[:i]
+			break
+		}
+	}
+	return v.check(node, rules)
+}
+
+// check applies rules to the value of node.
+func (v *validator) check(node *WalkNode, rules []validationRule) error {
+	value := node.Value
+	for value.IsPtr() && !value.IsNil() && value.Elem() != nil {
+		value = value.Elem()
+	}
+	if value.IsNil() {
+		// Only "required" applies to nil values.
+		for _, rule := range rules {
+			if rule.name == "required" {
+				return v.errs.add(&Error{Code: ERR_VALIDATION_FAILED, Path: node.Path, Message: rule.name})
+			}
+		}
+		return nil
+	}
+
+	var parent *StructReflector
+	if node.Field != nil {
+		parent, _ = newStructReflector(node.Parent.Value)
+	}
+
+	for _, rule := range rules {
+		if rule.name == "omitempty" {
+			if value.IsEmpty() {
+				return nil
+			}
+			continue
+		}
+
+		f := v.lookup(rule.name)
+		if f == nil {
+			return &Error{Code: ERR_UNKNOWN_VALIDATOR, Path: node.Path, Message: rule.name}
+		}
+		ok, err := f(value, rule.param, parent)
+		if err != nil {
+			return &Error{Code: ERR_INVALID_FIELD, Path: node.Path, Message: "invalid rule " + rule.name, Err: err}
+		}
+		if !ok {
+			message := rule.name
+			if rule.param != "" {
+				message += "=" + rule.param
+			}
+			// Only report the first failing rule of each value.
+			return v.errs.add(&Error{Code: ERR_VALIDATION_FAILED, Path: node.Path, Message: message})
+		}
+	}
+	return nil
+}
+
+// Validate checks the struct fields against the rules in their `validate`
+// tag, like `validate:"required,min=3,max=20"`.
+// Nested structs, and structs in slices and maps, are validated as well.
+// The rules before "dive" apply to a slice or map field, the rules after it
+// to each of its items.
+//
+// Builtin rules are required, omitempty, len, min, max, gt, gte, lt, lte
+// (lengths of strings, slices and maps, values otherwise), eq, ne,
+// oneof=a b c, email, regex=... (which must be the last rule) and eqfield,
+// nefield, gtfield, gtefield, ltfield, ltefield to compare fields of the
+// same struct. Custom rules can be registered with DefaultValidators.
+//
+// The first failing rule of each value is reported. All failures are
+// returned as a *MultiError of ERR_VALIDATION_FAILED errors, with the field
+// path and the rule as message.
+func (r *StructReflector) Validate() error {
+	return r.ValidateWith(nil)
+}
+
+// ValidateWith validates the struct, using the given options.
+// See Validate.
+func (r *StructReflector) ValidateWith(opts *ValidateOptions) error {
+	if opts == nil {
+		opts = &ValidateOptions{}
+	}
+	if opts.Tag == "" {
+		cp := *opts
+		cp.Tag = "validate"
+		opts = &cp
+	}
+
+	v := &validator{
+		opts: opts,
+		errs: &errorList{collect: true},
+		dive: make(map[*WalkNode][]validationRule),
+	}
+	if err := Walk(r.item, v.visit); err != nil {
+		return err
+	}
+	return v.errs.err()
+}
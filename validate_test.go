@@ -0,0 +1,164 @@
+package reflector_test
+
+import (
+	"errors"
+	"strings"
+
+	. "github.com/theduke/go-reflector"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type validateAddress struct {
+	Street string `validate:"required"`
+	Zip    string `validate:"len=4,regex=^[0-9]+$"`
+}
+
+type validateUser struct {
+	Name     string            `validate:"required,min=3,max=20"`
+	Email    string            `validate:"omitempty,email"`
+	Age      int               `validate:"gte=18,lt=150"`
+	Role     string            `validate:"oneof=admin user"`
+	Password string            `validate:"min=8"`
+	Confirm  string            `validate:"eqfield=Password"`
+	Tags     []string          `validate:"max=3,dive,min=2"`
+	Limits   map[string]int    `validate:"dive,gt=10"`
+	Address  *validateAddress  `validate:"required"`
+	Previous []validateAddress `validate:"-"`
+	Nickname *string           `validate:"min=2"`
+	Alias    *string           `validate:"min=2,required"`
+}
+
+var _ = Describe("Validate", func() {
+	var user *validateUser
+
+	BeforeEach(func() {
+		alias := "jj"
+		user = &validateUser{
+			Name:     "Jane",
+			Email:    "jane@example.com",
+			Age:      30,
+			Role:     "admin",
+			Password: "password",
+			Confirm:  "password",
+			Tags:     []string{"ab", "cd"},
+			Limits:   map[string]int{"a": 11},
+			Address:  &validateAddress{Street: "Main", Zip: "1010"},
+			Previous: []validateAddress{{}},
+			Alias:    &alias,
+		}
+	})
+
+	failures := func(err error) map[string]string {
+		result := make(map[string]string)
+		if err == nil {
+			return result
+		}
+		var multi *MultiError
+		Expect(errors.As(err, &multi)).To(BeTrue(), "%v", err)
+		for _, e := range multi.Errors {
+			Expect(e.Code).To(Equal(ERR_VALIDATION_FAILED))
+			result[e.Path] = e.Message
+		}
+		return result
+	}
+
+	It("Should accept valid structs", func() {
+		Expect(R(user).MustStruct().Validate()).To(Succeed())
+
+		user.Email = ""
+		Expect(R(user).MustStruct().Validate()).To(Succeed())
+	})
+
+	It("Should report all failures with paths", func() {
+		nick := "x"
+		user.Name = "Jo"
+		user.Email = "nope"
+		user.Age = 17
+		user.Role = "guest"
+		user.Confirm = "other"
+		user.Tags = []string{"a", "bc", "d", "e"}
+		user.Limits["b"] = 5
+		user.Address.Zip = "10a0"
+		user.Nickname = &nick
+
+		err := R(user).MustStruct().Validate()
+		Expect(errors.Is(err, ErrValidationFailed)).To(BeTrue())
+		Expect(failures(err)).To(Equal(map[string]string{
+			"Name":        "min=3",
+			"Email":       "email",
+			"Age":         "gte=18",
+			"Role":        "oneof=admin user",
+			"Confirm":     "eqfield=Password",
+			"Tags":        "max=3",
+			"Tags[0]":     "min=2",
+			"Tags[2]":     "min=2",
+			"Tags[3]":     "min=2",
+			"Limits.b":    "gt=10",
+			"Address.Zip": "regex=^[0-9]+$",
+			"Nickname":    "min=2",
+		}))
+	})
+
+	It("Should check required values", func() {
+		user.Name = ""
+		user.Address = nil
+		user.Alias = nil
+		Expect(failures(R(user).MustStruct().Validate())).To(Equal(map[string]string{
+			"Name":    "required",
+			"Address": "required",
+			"Alias":   "required",
+		}))
+	})
+
+	It("Should use custom validators", func() {
+		registry := NewValidatorRegistry()
+		registry.Register("upper", func(value *Reflector, _ string, _ *StructReflector) (bool, error) {
+			s := value.Interface().(string)
+			return s == strings.ToUpper(s), nil
+		})
+
+		s := &struct {
+			Code string `check:"upper"`
+		}{Code: "abc"}
+		err := R(s).MustStruct().ValidateWith(&ValidateOptions{Tag: "check", Validators: registry})
+		Expect(failures(err)).To(Equal(map[string]string{"Code": "upper"}))
+
+		err = R(s).MustStruct().ValidateWith(&ValidateOptions{Tag: "check"})
+		Expect(errors.Is(err, ErrUnknownValidator)).To(BeTrue())
+	})
+
+	It("Should compare against zero", func() {
+		s := &struct {
+			Count    int     `validate:"min=0"`
+			Name     string  `validate:"len=0"`
+			Balance  float64 `validate:"gt=0,max=100"`
+			Limit    float64 `validate:"gtfield=Floor"`
+			Floor    float64
+			Negative int `validate:"max=0"`
+		}{Count: 3, Name: "x", Balance: 1, Limit: 1, Negative: -2}
+		Expect(failures(R(s).MustStruct().Validate())).To(Equal(map[string]string{"Name": "len=0"}))
+
+		s.Count = -1
+		s.Name = ""
+		s.Balance = 0
+		s.Limit = -1
+		s.Negative = 1
+		Expect(failures(R(s).MustStruct().Validate())).To(Equal(map[string]string{
+			"Count":    "min=0",
+			"Balance":  "gt=0",
+			"Limit":    "gtfield=Floor",
+			"Negative": "max=0",
+		}))
+	})
+
+	It("Should report invalid rules", func() {
+		s := &struct {
+			Name string `validate:"eqfield=Nope"`
+		}{}
+		err := R(s).MustStruct().Validate()
+		Expect(errors.Is(err, ErrInvalidField)).To(BeTrue())
+		Expect(err.(*Error).Path).To(Equal("Name"))
+	})
+})
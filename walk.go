@@ -0,0 +1,266 @@
+package reflector
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+)
+
+// WalkNode is a value visited by Walk.
+type WalkNode struct {
+	// Path is the location of the node, like "Address.Lines[2]".
+	// Empty for the root value.
+	Path string
+
+	// Value is the value of the node. Interfaces are de-referenced, unless
+	// they are nil.
+	Value *Reflector
+
+	// Parent is the node that contains this node, or nil for the root.
+	Parent *WalkNode
+
+	// Field describes the struct field, for nodes that are struct fields.
+	Field *reflect.StructField
+
+	// Key is the map key, for nodes that are map entries.
+	Key *Reflector
+
+	// Depth is 0 for the root value.
+	Depth int
+
+	// slot is the location of the value. Map entries are walked on a copy
+	// that is stored in mapValue again if it was replaced.
+	slot     reflect.Value
+	mapValue reflect.Value
+	mapKey   reflect.Value
+	walker   *walker
+}
+
+func newWalkNode(w *walker, parent *WalkNode, path string, slot reflect.Value) *WalkNode {
+	node := &WalkNode{
+		Path:   path,
+		Parent: parent,
+		slot:   slot,
+		walker: w,
+	}
+	if parent != nil {
+		node.Depth = parent.Depth + 1
+	}
+	node.setValue()
+	return node
+}
+
+func (n *WalkNode) setValue() {
+	if n.slot.Kind() == reflect.Interface && !n.slot.IsNil() {
+		n.Value = &Reflector{value: n.slot.Elem()}
+	} else {
+		n.Value = &Reflector{value: n.slot}
+	}
+}
+
+// Replace replaces the value of the node.
+// The children of the new value are walked afterwards.
+// Replacing requires an addressable value, so the root passed to Walk must
+// be a pointer. Map entries can always be replaced.
+// If convert is true, the value is converted to the type of the node.
+func (n *WalkNode) Replace(value interface{}, convert ...bool) error {
+	val, ok := value.(*Reflector)
+	if !ok {
+		val = Reflect(value)
+	}
+	if err := assignValue(n.slot, val, convertOptions(convert)); err != nil {
+		return prefixPath(err, n.Path)
+	}
+	if n.mapValue.IsValid() {
+		n.mapValue.SetMapIndex(n.mapKey, n.slot)
+	}
+	n.walker.replaced++
+	n.setValue()
+	return nil
+}
+
+// WalkFunc is called for each node visited by Walk.
+// Returning ErrSkipNode skips the children of the node, and returning
+// ErrStopWalk stops walking. Other errors stop walking and are returned by
+// Walk.
+type WalkFunc func(node *WalkNode) error
+
+type walker struct {
+	visit WalkFunc
+	// active holds the pointers, maps and slices on the current path,
+	// to stop at cycles.
+	active map[copyKey]bool
+	// replaced counts calls to WalkNode.Replace. Copies of values are only
+	// stored again if they changed, so walking doesn't write to values.
+	replaced int
+}
+
+func (w *walker) walk(node *WalkNode) error {
+	if err := w.visit(node); err != nil {
+		if errors.Is(err, ErrSkipNode) {
+			return nil
+		}
+		return err
+	}
+	return w.children(node, node.slot)
+}
+
+// enter marks a reference as active. It returns false for cycles.
+func (w *walker) enter(key copyKey) bool {
+	if w.active[key] {
+		return false
+	}
+	w.active[key] = true
+	return true
+}
+
+// children walks the children of v, which is the value of parent or
+// the value it refers to.
+func (w *walker) children(parent *WalkNode, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		elem := v.Elem()
+		if (elem.Kind() == reflect.Struct || elem.Kind() == reflect.Array) && v.CanSet() {
+			// Values stored in an interface are not addressable, so walk
+			// a copy that can be modified, and store it again.
+			cp := reflect.New(elem.Type()).Elem()
+			cp.Set(elem)
+			replaced := w.replaced
+			err := w.children(parent, cp)
+			if w.replaced != replaced {
+				v.Set(cp)
+			}
+			return err
+		}
+		return w.children(parent, elem)
+
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		key := copyKey{ptr: v.Pointer(), typ: v.Type()}
+		if !w.enter(key) {
+			return nil
+		}
+		defer delete(w.active, key)
+		return w.children(parent, v.Elem())
+
+	case reflect.Struct:
+		typ := v.Type()
+		for i := 0; i < typ.NumField(); i++ {
+			info := typ.Field(i)
+			field := v.Field(i)
+			if info.PkgPath != "" {
+				// The exported fields of embedded structs of unexported
+				// types are accessible, so they are walked like fields of
+				// the parent.
+				if info.Anonymous {
+					if err := w.children(parent, field); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			node := newWalkNode(w, parent, joinPath(parent.Path, info.Name), field)
+			node.Field = &info
+			if err := w.walk(node); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		key := copyKey{ptr: v.Pointer(), typ: v.Type(), len: v.Len()}
+		if !w.enter(key) {
+			return nil
+		}
+		defer delete(w.active, key)
+		return w.items(parent, v)
+
+	case reflect.Array:
+		return w.items(parent, v)
+
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		key := copyKey{ptr: v.Pointer(), typ: v.Type()}
+		if !w.enter(key) {
+			return nil
+		}
+		defer delete(w.active, key)
+		return w.entries(parent, v)
+	}
+	return nil
+}
+
+func (w *walker) items(parent *WalkNode, v reflect.Value) error {
+	for i := 0; i < v.Len(); i++ {
+		if err := w.walk(newWalkNode(w, parent, joinPath(parent.Path, indexPath(i)), v.Index(i))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// entries walks map entries in the order of their keys.
+func (w *walker) entries(parent *WalkNode, v reflect.Value) error {
+	keys := make([]*Reflector, 0, v.Len())
+	for _, key := range v.MapKeys() {
+		keys = append(keys, &Reflector{value: key})
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return lessMapKey(keys[i], keys[j])
+	})
+
+	for _, key := range keys {
+		entry := v.MapIndex(key.value)
+		if !entry.IsValid() {
+			// Deleted by the visitor.
+			continue
+		}
+		// Map values are not addressable, so walk a copy.
+		item := reflect.New(v.Type().Elem()).Elem()
+		item.Set(entry)
+
+		node := newWalkNode(w, parent, joinPath(parent.Path, mapKeyString(key.value)), item)
+		node.Key = Reflect(key.value)
+		node.mapValue = v
+		node.mapKey = key.value
+		replaced := w.replaced
+		if err := w.walk(node); err != nil {
+			return err
+		}
+		if w.replaced != replaced && v.MapIndex(key.value).IsValid() {
+			v.SetMapIndex(key.value, item)
+		}
+	}
+	return nil
+}
+
+// Walk calls visit for value and every value reachable from it: struct
+// fields, slice and array items and map entries, in this order.
+// Pointers and interfaces are followed, the children of a pointer are the
+// children of the value it points to. Map entries are visited in the order
+// of their keys. Unexported struct fields are skipped, except for the
+// exported fields of embedded structs, and references that
+// lead back to a value on the current path are not followed, so cycles
+// are visited once.
+// See WalkFunc for skipping nodes and stopping, and WalkNode.Replace for
+// modifying values.
+func Walk(value interface{}, visit WalkFunc) error {
+	w := &walker{
+		visit:  visit,
+		active: make(map[copyKey]bool),
+	}
+	err := w.walk(newWalkNode(w, nil, "", reflectValue(value)))
+	if errors.Is(err, ErrStopWalk) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,191 @@
+package reflector_test
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	. "github.com/theduke/go-reflector"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type walkItem struct {
+	Name  string
+	Price float64
+}
+
+type walkOrder struct {
+	ID     int
+	Items  []*walkItem
+	Meta   map[string]interface{}
+	Notes  [2]string
+	Parent *walkOrder
+	Extra  interface{}
+	hidden string
+}
+
+type walkBase struct {
+	Created string
+}
+
+type walkEmbedded struct {
+	walkBase
+	*walkItem
+	Name string
+}
+
+var _ = Describe("Walk", func() {
+	var order *walkOrder
+
+	BeforeEach(func() {
+		order = &walkOrder{
+			ID:    1,
+			Items: []*walkItem{{"a", 1}, {"b", 2}},
+			Meta:  map[string]interface{}{"y": "1", "x": map[string]interface{}{"z": true}},
+			Notes: [2]string{"n1", "n2"},
+			Extra: walkItem{Name: "e"},
+		}
+	})
+
+	paths := func(value interface{}) []string {
+		result := make([]string, 0)
+		Expect(Walk(value, func(node *WalkNode) error {
+			result = append(result, node.Path)
+			return nil
+		})).To(Succeed())
+		return result
+	}
+
+	It("Should visit all reachable values", func() {
+		Expect(paths(order)).To(Equal([]string{
+			"",
+			"ID",
+			"Items",
+			"Items[0]", "Items[0].Name", "Items[0].Price",
+			"Items[1]", "Items[1].Name", "Items[1].Price",
+			"Meta", "Meta.x", "Meta.x.z", "Meta.y",
+			"Notes", "Notes[0]", "Notes[1]",
+			"Parent",
+			"Extra", "Extra.Name", "Extra.Price",
+		}))
+	})
+
+	It("Should provide node details", func() {
+		Expect(Walk(order, func(node *WalkNode) error {
+			switch node.Path {
+			case "Items[1].Name":
+				Expect(node.Field.Name).To(Equal("Name"))
+				Expect(node.Value.Interface()).To(Equal("b"))
+				Expect(node.Parent.Path).To(Equal("Items[1]"))
+				Expect(node.Parent.Parent.Path).To(Equal("Items"))
+				Expect(node.Depth).To(Equal(3))
+			case "Meta.y":
+				Expect(node.Key.Interface()).To(Equal("y"))
+				Expect(node.Value.Interface()).To(Equal("1"))
+			case "Extra":
+				Expect(node.Value.Interface()).To(Equal(walkItem{Name: "e"}))
+			}
+			return nil
+		})).To(Succeed())
+	})
+
+	It("Should skip subtrees and stop", func() {
+		visited := make([]string, 0)
+		err := Walk(order, func(node *WalkNode) error {
+			visited = append(visited, node.Path)
+			if node.Path == "Items" || node.Path == "Meta" {
+				return ErrSkipNode
+			}
+			if node.Path == "Notes[0]" {
+				return ErrStopWalk
+			}
+			return nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(visited).To(Equal([]string{"", "ID", "Items", "Meta", "Notes", "Notes[0]"}))
+
+		failure := errors.New("failure")
+		Expect(Walk(order, func(node *WalkNode) error {
+			return failure
+		})).To(Equal(failure))
+	})
+
+	It("Should replace values in place", func() {
+		err := Walk(order, func(node *WalkNode) error {
+			if node.Value.IsString() {
+				return node.Replace(strings.ToUpper(node.Value.Interface().(string)))
+			}
+			if node.Path == "Meta.x" {
+				return node.Replace(map[string]interface{}{"w": "new"})
+			}
+			return nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(order.Items[0].Name).To(Equal("A"))
+		Expect(order.Meta).To(Equal(map[string]interface{}{"y": "1", "x": map[string]interface{}{"w": "NEW"}}))
+		Expect(order.Notes).To(Equal([2]string{"N1", "N2"}))
+		Expect(order.Extra).To(Equal(walkItem{Name: "E"}))
+	})
+
+	It("Should convert replacements", func() {
+		Expect(Walk(order, func(node *WalkNode) error {
+			if node.Path == "ID" {
+				return node.Replace("42", true)
+			}
+			return nil
+		})).To(Succeed())
+		Expect(order.ID).To(Equal(42))
+
+		err := Walk(order, func(node *WalkNode) error {
+			if node.Path == "ID" {
+				return node.Replace("x")
+			}
+			return nil
+		})
+		Expect(errors.Is(err, ErrTypeMismatch)).To(BeTrue())
+		Expect(err.(*Error).Path).To(Equal("ID"))
+	})
+
+	It("Should walk exported fields of embedded unexported types", func() {
+		value := &walkEmbedded{walkItem: &walkItem{Name: "i"}}
+		Expect(paths(value)).To(Equal([]string{"", "Created", "Name", "Price", "Name"}))
+
+		Expect(Walk(value, func(node *WalkNode) error {
+			if node.Path == "Created" {
+				return node.Replace("now")
+			}
+			return nil
+		})).To(Succeed())
+		Expect(value.Created).To(Equal("now"))
+	})
+
+	It("Should not write to values without replacements", func() {
+		// Run with -race to detect writes.
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				Expect(Walk(order, func(node *WalkNode) error {
+					return nil
+				})).To(Succeed())
+			}()
+		}
+		wg.Wait()
+	})
+
+	It("Should stop at cycles", func() {
+		order.Parent = order
+		m := map[string]interface{}{}
+		m["self"] = m
+		order.Meta = m
+
+		Expect(paths(order)).To(ContainElements("Parent", "Meta.self"))
+		Expect(paths(order)).ToNot(ContainElement("Parent.ID"))
+		Expect(paths(order)).ToNot(ContainElement("Meta.self.self"))
+	})
+})